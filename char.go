@@ -0,0 +1,25 @@
+package main
+
+import (
+	"github.com/silbinarywolf/networkplatformer-go/game"
+)
+
+type Char struct {
+	game.Char
+
+	// Set for players other than you. They are drawn between the states
+	// received from the server instead of simulated.
+	snapshots             *snapshotBuffer
+	connectionInterrupted bool
+}
+
+func (c *Char) RemoveFromSimulation() {
+	// Unordered remove
+	for i, char := range chars {
+		if char == c {
+			chars[i] = chars[len(chars)-1] // Replace it with the last one.
+			chars = chars[:len(chars)-1]   // delete last element
+			return
+		}
+	}
+}
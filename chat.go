@@ -0,0 +1,127 @@
+package main
+
+import (
+	"image/color"
+	"time"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+	"github.com/hajimehoshi/ebiten/inpututil"
+	"github.com/hajimehoshi/ebiten/text"
+	"github.com/silbinarywolf/networkplatformer-go/game"
+)
+
+const (
+	// Most lines kept in the chat log
+	maxChatLines = 50
+
+	// Lines of the log shown at once
+	chatVisibleLines = 8
+
+	// How long lines stay on screen while the player isn't typing
+	chatLineTime = 10 * time.Second
+
+	chatLineHeight = 16
+)
+
+var (
+	chat chatBox
+
+	chatBackgroundColor = color.RGBA{0, 0, 0, 0x80}
+)
+
+type chatLine struct {
+	text     string
+	received time.Time
+}
+
+// chatBox is the chat overlay. Enter starts typing a message and sends it,
+// Escape cancels. While typing, Up and Down scroll through the log.
+type chatBox struct {
+	// Whether the player is typing, controls don't move them while they are
+	typing bool
+
+	input []rune
+	lines []chatLine
+
+	// How many lines up from the newest the log is scrolled
+	scroll int
+}
+
+// Add puts a line at the bottom of the log.
+func (c *chatBox) Add(text string) {
+	c.lines = append(c.lines, chatLine{
+		text:     text,
+		received: time.Now(),
+	})
+	if len(c.lines) > maxChatLines {
+		c.lines = c.lines[len(c.lines)-maxChatLines:]
+	}
+	if c.scroll > 0 {
+		// Keep showing the same lines when scrolled back
+		c.scroll++
+	}
+}
+
+// Update handles typing and returns the message to send, if the player
+// finished one this frame.
+func (c *chatBox) Update() string {
+	if !c.typing {
+		if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+			c.typing = true
+			c.scroll = 0
+		}
+		return ""
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		c.typing = false
+		c.input = c.input[:0]
+		return ""
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		text := game.SanitizeChat(string(c.input))
+		c.typing = false
+		c.input = c.input[:0]
+		c.scroll = 0
+		return text
+	}
+	for _, r := range ebiten.InputChars() {
+		if game.IsPrintable(r) && len(c.input) < game.MaxChatLength {
+			c.input = append(c.input, r)
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && len(c.input) > 0 {
+		c.input = c.input[:len(c.input)-1]
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyUp) && c.scroll < len(c.lines)-chatVisibleLines {
+		c.scroll++
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyDown) && c.scroll > 0 {
+		c.scroll--
+	}
+	return ""
+}
+
+// Draw draws the log in the bottom left of the screen, with the message
+// being typed under it.
+func (c *chatBox) Draw(screen *ebiten.Image) {
+	x := 8
+	y := screenHeight - 8
+	if c.typing {
+		top := y - (chatVisibleLines+1)*chatLineHeight
+		ebitenutil.DrawRect(screen, 0, float64(top), screenWidth, float64(screenHeight-top), chatBackgroundColor)
+		text.Draw(screen, "> "+string(c.input)+"_", nameplateFace, x, y, color.White)
+	}
+	y -= chatLineHeight
+
+	now := time.Now()
+	end := len(c.lines) - c.scroll
+	for i := end - 1; i >= 0 && i >= end-chatVisibleLines; i-- {
+		line := c.lines[i]
+		if !c.typing && now.Sub(line.received) > chatLineTime {
+			break
+		}
+		text.Draw(screen, line.text, nameplateFace, x, y, color.White)
+		y -= chatLineHeight
+	}
+}
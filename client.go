@@ -1,35 +1,81 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"time"
 
-	"github.com/gogo/protobuf/proto"
+	"github.com/silbinarywolf/networkplatformer-go/game"
 	"github.com/silbinarywolf/networkplatformer-go/gameclient"
+	"github.com/silbinarywolf/networkplatformer-go/level"
 	"github.com/silbinarywolf/networkplatformer-go/netmsg"
 )
 
-const (
-	maxClients = 256
-)
-
 var (
-	client               *Client
-	isConnected          = false
-	lastWorldUpdateTimer time.Time
+	client      *Client
+	isConnected = false
 )
 
 type Client struct {
 	*gameclient.Client
+
+	// How far in the past other players are drawn. Keeping a delay
+	// means there is usually an update either side of the time drawn.
+	InterpolationDelay time.Duration
+
+	// How far other players keep moving past their newest update when
+	// updates are late.
+	MaxExtrapolation time.Duration
+
 	clientSlots []*Char
+
+	// Names of the players in each slot, from PlayerJoined
+	names []string
+
+	// Slot the server gave us
+	clientSlot int32
+
+	// Sequence number of the last input sent to the server
+	inputSequence uint32
+
+	// Inputs sent to the server, replayed on top of the server's state
+	// for inputs it hasn't processed yet.
+	pendingInputs inputBuffer
+
+	// Sequence number of the last input the server said it processed
+	lastAckedInput uint32
+
+	// Snapshots we've received, used as baselines for delta snapshots
+	snapshots netmsg.SnapshotHistory
+
+	// Tick of the newest snapshot we've applied, sent back to the
+	// server so it knows what to send deltas against.
+	lastSnapshotTick uint64
+
+	// Decodes positions and velocities, as configured by the server
+	quantizer netmsg.Quantizer
+
+	// Routes messages from the server to the handle* methods
+	dispatcher netmsg.Dispatcher
+
+	// Set once we've disconnected for good, because the server sent
+	// something we can't play with
+	closed bool
 }
 
 func NewClient() *Client {
-	server := &Client{
-		Client:      gameclient.NewClient(),
-		clientSlots: make([]*Char, maxClients),
+	c := &Client{
+		Client:             gameclient.NewClient(),
+		InterpolationDelay: 100 * time.Millisecond,
+		MaxExtrapolation:   50 * time.Millisecond,
 	}
-	return server
+	c.dispatcher.Handle(c.handleConnectResponse)
+	c.dispatcher.Handle(c.handleWorldSnapshot)
+	c.dispatcher.Handle(c.handleDisconnectPlayer)
+	c.dispatcher.Handle(c.handleServerShutdown)
+	c.dispatcher.Handle(c.handlePlayerJoined)
+	c.dispatcher.Handle(c.handleChatMessage)
+	return c
 }
 
 func (c *Client) Update() {
@@ -37,97 +83,291 @@ RecvMsgLoop:
 	for {
 		select {
 		case buf := <-c.ChRecv():
-			kind := netmsg.Kind(buf[0])
-			buf = buf[1:]
-			switch kind {
-			case netmsg.MsgConnectResponse:
-				recvMsg := &netmsg.ConnectResponse{}
-				err := recvMsg.Unmarshal(buf)
-				if err != nil {
-					log.Fatal("marshaling error: ", err)
-					break
-				}
-
-				// Receive starting pos from server and add to chars to simulate
-				you.X = recvMsg.X
-				you.Y = recvMsg.Y
-				chars = append(chars, you)
-				isConnected = true
-
-				// Last time we received an update about the world
-				lastWorldUpdateTimer = time.Now()
-
-				log.Printf("%s: received login data: %v\n", kind, recvMsg)
-			case netmsg.MsgUpdatePlayer:
-				recvMsg := &netmsg.UpdatePlayer{}
-				err := recvMsg.Unmarshal(buf)
-				if err != nil {
-					log.Fatal("marshaling error: ", err)
-					break
-				}
-				clientSlot := recvMsg.GetClientSlot()
-				char := c.clientSlots[clientSlot]
-				if char == nil {
-					// Create char if they don't exist
-					char = &Char{}
-					chars = append(chars, char)
-					c.clientSlots[clientSlot] = char
-				}
-				char.X = recvMsg.X
-				char.Y = recvMsg.Y
-				char.isKeyLeftPressed = recvMsg.IsKeyLeftPressed
-				char.isKeyRightPressed = recvMsg.IsKeyRightPressed
-			case netmsg.MsgDisconnectPlayer:
-				recvMsg := &netmsg.DisconnectPlayer{}
-				err := recvMsg.Unmarshal(buf)
-				if err != nil {
-					log.Fatal("marshaling error: ", err)
-					break
-				}
-				clientSlot := recvMsg.GetClientSlot()
-				char := c.clientSlots[clientSlot]
-				if char == nil {
-					continue
-				}
-				char.RemoveFromSimulation()
-				c.clientSlots[clientSlot] = nil
-			default:
-				log.Printf("Unhandled netmsg kind: %s, with data: %v", kind.String(), buf)
+			if err := c.dispatcher.Dispatch(buf); err != nil {
+				log.Printf("Failed to handle message from server: %v", err)
 			}
 		case <-c.ChDisconnected():
 			isConnected = false
 			you.RemoveFromSimulation()
 
-			log.Println("Lost connection to server")
+			if c.closed {
+				log.Println("Disconnected from server")
+			} else {
+				log.Println("Lost connection to server, reconnecting")
+				chat.Add("Lost connection to server, reconnecting...")
+			}
+		case err := <-c.ChReconnectFailed():
+			log.Printf("Gave up reconnecting: %v", err)
+			chat.Add("Couldn't reconnect to server.")
 		default:
 			// no more messages
 			break RecvMsgLoop
 		}
 	}
+}
 
-	//
-	if you != nil && isConnected {
-		elapsed := time.Since(lastWorldUpdateTimer)
-		if elapsed > 15*time.Millisecond {
-			lastWorldUpdateTimer = time.Now()
+func (c *Client) handleConnectResponse(recvMsg *netmsg.ConnectResponse) {
+	// The server decides how many players there can be and what
+	// level we're playing
+	lvl, err := level.Load(recvMsg.Level)
+	if err != nil {
+		// Reconnecting would only get us the same level, so give up
+		c.disconnect(fmt.Sprintf("Can't play level %q: %v", recvMsg.Level, err))
+		return
+	}
+	if recvMsg.ClientSlot < 0 || recvMsg.ClientSlot >= recvMsg.MaxClients {
+		c.disconnect(fmt.Sprintf("Server gave us slot %d of %d.", recvMsg.ClientSlot, recvMsg.MaxClients))
+		return
+	}
+	c.clientSlots = make([]*Char, recvMsg.MaxClients)
+	c.names = make([]string, recvMsg.MaxClients)
+	currentLevel = lvl
+	currentTick = recvMsg.Tick
 
-			// Send update data to server
-			sendMsg := netmsg.UpdatePlayer{
-				X:                 you.X,
-				Y:                 you.Y,
-				IsKeyLeftPressed:  you.isKeyLeftPressed,
-				IsKeyRightPressed: you.isKeyRightPressed,
-			}
-			data, err := proto.Marshal(&sendMsg)
-			if err != nil {
-				log.Fatal("client update: marshaling error: ", err)
+	// Receive starting pos from server and add to chars to simulate
+	c.quantizer = netmsg.Quantizer{
+		Precision: recvMsg.PositionPrecision,
+	}
+	you.X = c.quantizer.Decode(recvMsg.X)
+	you.Y = c.quantizer.Decode(recvMsg.Y)
+	you.Name = recvMsg.Name
+
+	// Start over with just us, the other players come back with the next
+	// snapshot if this is a reconnect.
+	chars = append(chars[:0], you)
+
+	c.clientSlot = recvMsg.ClientSlot
+	c.clientSlots[recvMsg.ClientSlot] = you
+	c.inputSequence = 0
+	c.lastAckedInput = 0
+	c.snapshots = netmsg.SnapshotHistory{}
+	c.lastSnapshotTick = 0
+	isConnected = true
+
+	log.Printf("%s: received login data: %v\n", netmsg.MsgConnectResponse, recvMsg)
+}
+
+// disconnect closes the connection for good because of something the
+// server sent, showing why in chat.
+func (c *Client) disconnect(reason string) {
+	log.Printf("Disconnecting: %s", reason)
+	chat.Add(reason + " Disconnecting.")
+	c.closed = true
+	c.Close()
+}
+
+func (c *Client) handleWorldSnapshot(recvMsg *netmsg.WorldSnapshot) {
+	var baseline netmsg.Players
+	if recvMsg.BaselineTick != 0 {
+		var ok bool
+		baseline, ok = c.snapshots.Get(recvMsg.BaselineTick)
+		if !ok {
+			// Can't decode it. We keep acknowledging the last
+			// snapshot we have, so the server falls back to a
+			// full snapshot once it forgets that one.
+			log.Printf("%s: missing baseline %d for %d", netmsg.MsgWorldSnapshot, recvMsg.BaselineTick, recvMsg.Tick)
+			return
+		}
+	}
+	players := netmsg.ApplyDeltaSnapshot(baseline, recvMsg)
+	c.snapshots.Add(recvMsg.Tick, players)
+	c.lastSnapshotTick = recvMsg.Tick
+	c.applySnapshot(players, recvMsg.InputSequence)
+}
+
+func (c *Client) handleDisconnectPlayer(recvMsg *netmsg.DisconnectPlayer) {
+	clientSlot := recvMsg.GetClientSlot()
+	if clientSlot < 0 || int(clientSlot) >= len(c.clientSlots) {
+		return
+	}
+	c.names[clientSlot] = ""
+	char := c.clientSlots[clientSlot]
+	if char == nil {
+		return
+	}
+	char.RemoveFromSimulation()
+	c.clientSlots[clientSlot] = nil
+}
+
+func (c *Client) handlePlayerJoined(recvMsg *netmsg.PlayerJoined) {
+	clientSlot := recvMsg.GetClientSlot()
+	if clientSlot < 0 || int(clientSlot) >= len(c.names) {
+		return
+	}
+	c.names[clientSlot] = recvMsg.Name
+	if char := c.clientSlots[clientSlot]; char != nil {
+		char.Name = recvMsg.Name
+	}
+}
+
+func (c *Client) handleChatMessage(recvMsg *netmsg.ChatMessage) {
+	text := game.SanitizeChat(recvMsg.Text)
+	if recvMsg.ClientSlot >= 0 {
+		text = game.SanitizeName(recvMsg.Name) + ": " + text
+	}
+	chat.Add(text)
+}
+
+func (c *Client) handleServerShutdown(recvMsg *netmsg.ServerShutdown) {
+	log.Printf("Server is shutting down: %s", recvMsg.Reason)
+}
+
+// SendChat sends a chat message to everyone.
+func (c *Client) SendChat(text string) {
+	if !isConnected {
+		return
+	}
+	c.send(&netmsg.ChatSend{
+		Text: text,
+	})
+}
+
+// send encodes msg and sends it to the server.
+func (c *Client) send(msg netmsg.Message) {
+	packetData, err := netmsg.Encode(msg)
+	if err != nil {
+		log.Printf("Failed to encode message for server: %v", err)
+		return
+	}
+	c.SendMessage(packetData)
+}
+
+// applySnapshot updates every player from a snapshot of the world, so they
+// all move together. inputSequence is the last of our inputs the server
+// had processed.
+func (c *Client) applySnapshot(players netmsg.Players, inputSequence uint32) {
+	received := time.Now()
+	for clientSlot, state := range players {
+		if clientSlot < 0 || int(clientSlot) >= len(c.clientSlots) {
+			// Not a slot the server told us about, or we haven't had
+			// ConnectResponse yet
+			continue
+		}
+		if clientSlot == c.clientSlot {
+			c.reconcile(state, inputSequence)
+			continue
+		}
+		char := c.clientSlots[clientSlot]
+		if char == nil {
+			// Create char if they don't exist
+			char = &Char{
+				Char: game.Char{
+					X:    c.quantizer.Decode(state.X),
+					Y:    c.quantizer.Decode(state.Y),
+					Name: c.names[clientSlot],
+				},
+				snapshots: &snapshotBuffer{},
 			}
+			chars = append(chars, char)
+			c.clientSlots[clientSlot] = char
+		}
+		char.snapshots.Add(charSnapshot{
+			time: received,
+			X:    c.quantizer.Decode(state.X),
+			Y:    c.quantizer.Decode(state.Y),
+			VX:   c.quantizer.Decode(state.VX),
+			VY:   c.quantizer.Decode(state.VY),
+		})
+		char.IsKeyLeftPressed = state.IsKeyLeftPressed
+		char.IsKeyRightPressed = state.IsKeyRightPressed
+		char.IsKeyJumpPressed = state.IsKeyJumpPressed
+		char.connectionInterrupted = state.ConnectionInterrupted
+	}
+}
+
+// Interpolate moves other players to where they were InterpolationDelay
+// ago, based on the updates received from the server.
+func (c *Client) Interpolate() {
+	renderTime := time.Now().Add(-c.InterpolationDelay)
+	for _, char := range chars {
+		if char.snapshots == nil {
+			continue
+		}
+		x, y, ok := char.snapshots.Sample(renderTime, c.MaxExtrapolation)
+		if !ok {
+			continue
+		}
+		char.X = x
+		char.Y = y
+	}
+}
+
+// SendInput records the local player's controls for this frame and sends
+// them to the server. The input is applied locally straight away by
+// simulate(), rather than waiting for the server to reply.
+func (c *Client) SendInput() {
+	if you == nil || !isConnected {
+		return
+	}
+	c.inputSequence++
+	input := game.Input{
+		Sequence: c.inputSequence,
+		Left:     you.IsKeyLeftPressed,
+		Right:    you.IsKeyRightPressed,
+		Jump:     you.IsKeyJumpPressed,
+	}
+	c.pendingInputs.Add(input)
 
-			// send update message to server
-			packetData := make([]byte, 1, len(data)+1)
-			packetData[0] = netmsg.MsgUpdatePlayer
-			packetData = append(packetData, data...)
-			client.SendMessage(packetData)
+	// Send inputs to server. Our position is included so the
+	// server can check we haven't drifted from it.
+	sendMsg := netmsg.UpdatePlayer{
+		InputSequence:     input.Sequence,
+		X:                 c.quantizer.Encode(you.X),
+		Y:                 c.quantizer.Encode(you.Y),
+		IsKeyLeftPressed:  input.Left,
+		IsKeyRightPressed: input.Right,
+		IsKeyJumpPressed:  input.Jump,
+		Tick:              currentTick,
+		SnapshotAck:       c.lastSnapshotTick,
+	}
+	c.send(&sendMsg)
+}
+
+// reconcile moves the local player to the state the server simulated after
+// the last input it processed, then replays the inputs the server hasn't
+// processed yet. If our prediction was right this changes nothing.
+func (c *Client) reconcile(state *netmsg.PlayerState, inputSequence uint32) {
+	if inputSequence < c.lastAckedInput {
+		// Older than what we've already reconciled against
+		return
+	}
+	c.lastAckedInput = inputSequence
+
+	you.X = c.quantizer.Decode(state.X)
+	you.Y = c.quantizer.Decode(state.Y)
+	you.VX = c.quantizer.Decode(state.VX)
+	you.VY = c.quantizer.Decode(state.VY)
+	for sequence := inputSequence + 1; sequence <= c.inputSequence; sequence++ {
+		input, ok := c.pendingInputs.Get(sequence)
+		if !ok {
+			// Too old, it was overwritten in the buffer
+			continue
 		}
+		you.ApplyInput(input)
+		you.Step(currentLevel, game.TickDuration.Seconds())
+	}
+}
+
+// inputBufferSize is how many inputs are remembered for replaying, about
+// two seconds worth at 60 frames per second.
+const inputBufferSize = 128
+
+// inputBuffer is a ring buffer of the inputs sent to the server, indexed by
+// sequence number.
+type inputBuffer struct {
+	inputs [inputBufferSize]game.Input
+}
+
+func (b *inputBuffer) Add(input game.Input) {
+	b.inputs[input.Sequence%inputBufferSize] = input
+}
+
+// Get returns the input with the given sequence number, if it is still in
+// the buffer.
+func (b *inputBuffer) Get(sequence uint32) (game.Input, bool) {
+	input := b.inputs[sequence%inputBufferSize]
+	if input.Sequence != sequence {
+		return game.Input{}, false
 	}
+	return input, true
 }
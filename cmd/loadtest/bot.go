@@ -0,0 +1,229 @@
+package main
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/silbinarywolf/networkplatformer-go/game"
+	"github.com/silbinarywolf/networkplatformer-go/gameclient"
+	"github.com/silbinarywolf/networkplatformer-go/level"
+	"github.com/silbinarywolf/networkplatformer-go/netmsg"
+)
+
+const (
+	// How often random bots change what they are pressing
+	randomInputChange = 500 * time.Millisecond
+
+	// Most inputs kept for replaying, older ones are forgotten if the
+	// server stops processing them
+	maxPendingInputs = 128
+)
+
+// bot is a headless player. It sends inputs and predicts where they take
+// it like the game does, and keeps up with snapshots so it can acknowledge
+// them and be corrected by the server.
+type bot struct {
+	client *gameclient.Client
+	stats  *stats
+
+	// Makes up random inputs
+	rand *rand.Rand
+
+	// Whether inputs follow a fixed pattern rather than being random
+	scripted bool
+
+	// Slot the server gave us
+	clientSlot int32
+
+	// Decodes positions, as configured by the server
+	quantizer netmsg.Quantizer
+
+	// The level the server runs, for predicting our movement
+	level *level.Level
+
+	// Where we predict we are
+	char game.Char
+
+	// Inputs sent that the server hasn't processed yet
+	pendingInputs []game.Input
+
+	// Sequence number of the last input sent to the server
+	inputSequence uint32
+
+	// Buttons currently held
+	left, right, jump bool
+	nextChange        time.Time
+
+	// Snapshots we've received, used as baselines for delta snapshots
+	snapshots        netmsg.SnapshotHistory
+	lastSnapshotTick uint64
+
+	// Routes messages from the server to the handle* methods
+	dispatcher netmsg.Dispatcher
+}
+
+func newBot(client *gameclient.Client, stats *stats, seed int64, scripted bool) *bot {
+	b := &bot{
+		client:   client,
+		stats:    stats,
+		rand:     rand.New(rand.NewSource(seed)),
+		scripted: scripted,
+	}
+	b.dispatcher.Handle(b.handleConnectResponse)
+	b.dispatcher.Handle(b.handleWorldSnapshot)
+	return b
+}
+
+// run plays until stop is closed or the bot loses connection, sending an
+// input every sendInterval.
+func (b *bot) run(sendInterval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(sendInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case buf := <-b.client.ChRecv():
+			b.stats.received(len(buf))
+			// Bots only care about a few messages
+			if err := b.dispatcher.Dispatch(buf); err != nil {
+				if _, ok := err.(*netmsg.UnhandledError); !ok {
+					b.stats.fail(err)
+				}
+			}
+		case now := <-ticker.C:
+			b.sendInput(now)
+		case <-b.client.ChDisconnected():
+			b.stats.disconnected()
+			return
+		case <-stop:
+			b.client.Close()
+			return
+		}
+	}
+}
+
+func (b *bot) handleConnectResponse(recvMsg *netmsg.ConnectResponse) {
+	lvl, err := level.Load(recvMsg.Level)
+	if err != nil {
+		b.stats.fail(err)
+		b.client.Close()
+		return
+	}
+	b.level = lvl
+	b.clientSlot = recvMsg.ClientSlot
+	b.quantizer = netmsg.Quantizer{
+		Precision: recvMsg.PositionPrecision,
+	}
+	b.char = game.Char{
+		X: b.quantizer.Decode(recvMsg.X),
+		Y: b.quantizer.Decode(recvMsg.Y),
+	}
+	b.pendingInputs = b.pendingInputs[:0]
+	b.inputSequence = 0
+	b.snapshots = netmsg.SnapshotHistory{}
+	b.lastSnapshotTick = 0
+}
+
+func (b *bot) handleWorldSnapshot(recvMsg *netmsg.WorldSnapshot) {
+	var baseline netmsg.Players
+	if recvMsg.BaselineTick != 0 {
+		var ok bool
+		baseline, ok = b.snapshots.Get(recvMsg.BaselineTick)
+		if !ok {
+			return
+		}
+	}
+	players := netmsg.ApplyDeltaSnapshot(baseline, recvMsg)
+	b.snapshots.Add(recvMsg.Tick, players)
+	b.lastSnapshotTick = recvMsg.Tick
+	if state, ok := players[b.clientSlot]; ok {
+		b.reconcile(state, recvMsg.InputSequence)
+	}
+}
+
+// reconcile moves the bot to where the server says it was after the last
+// input it processed, then replays the inputs it hasn't processed yet, the
+// same way the game does.
+func (b *bot) reconcile(state *netmsg.PlayerState, inputSequence uint32) {
+	processed := 0
+	for processed < len(b.pendingInputs) && b.pendingInputs[processed].Sequence <= inputSequence {
+		processed++
+	}
+	b.pendingInputs = append(b.pendingInputs[:0], b.pendingInputs[processed:]...)
+
+	b.char.X = b.quantizer.Decode(state.X)
+	b.char.Y = b.quantizer.Decode(state.Y)
+	b.char.VX = b.quantizer.Decode(state.VX)
+	b.char.VY = b.quantizer.Decode(state.VY)
+	for _, input := range b.pendingInputs {
+		b.char.ApplyInput(input)
+		b.char.Step(b.level, game.TickDuration.Seconds())
+	}
+}
+
+// sendInput picks what to press, sends it along with where we are, then
+// moves as the server will once it processes the input.
+func (b *bot) sendInput(now time.Time) {
+	if b.level == nil {
+		// Not joined yet
+		return
+	}
+	b.inputSequence++
+	if b.scripted {
+		b.scriptedInput()
+	} else {
+		b.randomInput(now)
+	}
+	packetData, err := netmsg.Encode(&netmsg.UpdatePlayer{
+		InputSequence:     b.inputSequence,
+		X:                 b.quantizer.Encode(b.char.X),
+		Y:                 b.quantizer.Encode(b.char.Y),
+		IsKeyLeftPressed:  b.left,
+		IsKeyRightPressed: b.right,
+		IsKeyJumpPressed:  b.jump,
+		SnapshotAck:       b.lastSnapshotTick,
+	})
+	if err != nil {
+		b.stats.fail(err)
+		return
+	}
+	b.client.SendMessage(packetData)
+	b.stats.sent(len(packetData))
+
+	input := game.Input{
+		Sequence: b.inputSequence,
+		Left:     b.left,
+		Right:    b.right,
+		Jump:     b.jump,
+	}
+	if len(b.pendingInputs) >= maxPendingInputs {
+		b.pendingInputs = append(b.pendingInputs[:0], b.pendingInputs[1:]...)
+	}
+	b.pendingInputs = append(b.pendingInputs, input)
+	b.char.ApplyInput(input)
+	b.char.Step(b.level, game.TickDuration.Seconds())
+}
+
+// scriptedInput runs right, then left, jumping every so often.
+func (b *bot) scriptedInput() {
+	step := b.inputSequence % 120
+	b.right = step < 60
+	b.left = !b.right
+	b.jump = b.inputSequence%45 < 10
+}
+
+// randomInput holds random buttons for a while before picking again.
+func (b *bot) randomInput(now time.Time) {
+	if now.Before(b.nextChange) {
+		return
+	}
+	b.nextChange = now.Add(randomInputChange)
+	switch b.rand.Intn(3) {
+	case 0:
+		b.left, b.right = true, false
+	case 1:
+		b.left, b.right = false, true
+	default:
+		b.left, b.right = false, false
+	}
+	b.jump = b.rand.Intn(4) == 0
+}
@@ -0,0 +1,136 @@
+// Command loadtest connects lots of headless bots to a server to find out
+// how many players it holds. Bots join, send inputs at the rate the game
+// does and acknowledge snapshots, while loadtest reports connection
+// failures, message rates, bandwidth and the round-trip times the server
+// measures to them.
+//
+//	go run ./cmd/loadtest --host localhost:8080 --bots 200
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/silbinarywolf/networkplatformer-go/game"
+	"github.com/silbinarywolf/networkplatformer-go/gameclient"
+)
+
+func main() {
+	var (
+		host           string
+		path           string
+		name           string
+		botCount       int
+		spawnInterval  time.Duration
+		sendInterval   time.Duration
+		reportInterval time.Duration
+		duration       time.Duration
+		scripted       bool
+		seed           int64
+	)
+	flag.StringVar(&host, "host", "localhost:8080", "Server address the bots connect to")
+	flag.StringVar(&path, "path", "/ws", "Path of the websocket endpoint")
+	flag.StringVar(&name, "name", "bot", "Name the bots join with, followed by their number")
+	flag.IntVar(&botCount, "bots", 100, "Number of bots to connect")
+	flag.DurationVar(&spawnInterval, "spawn-interval", 10*time.Millisecond, "Time between bots joining")
+	flag.DurationVar(&sendInterval, "send-interval", game.TickDuration, "Time between inputs sent by each bot, the game sends one every tick")
+	flag.DurationVar(&reportInterval, "report-interval", 5*time.Second, "Time between reports")
+	flag.DurationVar(&duration, "duration", 0, "How long to run for, 0 to run until interrupted")
+	flag.BoolVar(&scripted, "scripted", false, "Send the same pattern of inputs from every bot rather than random ones")
+	flag.Int64Var(&seed, "seed", time.Now().UnixNano(), "Seed for random inputs")
+	flag.Parse()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	var timeout <-chan time.Time
+	if duration > 0 {
+		timeout = time.After(duration)
+	}
+
+	var (
+		stats   stats
+		stop    = make(chan struct{})
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		clients = make(map[*gameclient.Client]bool)
+	)
+
+	// Round-trip times the server measured to each connected bot, for
+	// those it has measured.
+	serverRTTs := func() []time.Duration {
+		mu.Lock()
+		defer mu.Unlock()
+		latencies := make([]time.Duration, 0, len(clients))
+		for client := range clients {
+			if rtt := client.ServerRTT(); rtt > 0 {
+				latencies = append(latencies, rtt)
+			}
+		}
+		return latencies
+	}
+
+	// Bots join one at a time, like players arriving
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(spawnInterval)
+		defer ticker.Stop()
+		for i := 0; i < botCount; i++ {
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				client := gameclient.NewClient()
+				client.SetPath(path)
+				client.SetName(fmt.Sprintf("%s %d", name, i+1))
+				if err := client.Dial(host); err != nil {
+					stats.joinFailed(err)
+					return
+				}
+				client.Listen()
+				stats.join()
+				mu.Lock()
+				clients[client] = true
+				mu.Unlock()
+				newBot(client, &stats, seed+int64(i), scripted).run(sendInterval, stop)
+				mu.Lock()
+				delete(clients, client)
+				mu.Unlock()
+			}(i)
+		}
+	}()
+
+	log.Printf("Connecting %d bots to %s", botCount, host)
+	start := stats.snapshot(time.Now())
+	prev := start
+	ticker := time.NewTicker(reportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			cur := stats.snapshot(now)
+			log.Print(report(prev, cur, serverRTTs()))
+			prev = cur
+			continue
+		case <-timeout:
+		case sig := <-interrupt:
+			log.Printf("Received %s, stopping", sig)
+		}
+		break
+	}
+
+	latencies := serverRTTs()
+	close(stop)
+	wg.Wait()
+	log.Printf("Total: %s", report(start, stats.snapshot(time.Now()), latencies))
+}
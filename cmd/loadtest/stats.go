@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Most errors logged per report, so a failing server doesn't flood the
+// output.
+const maxLoggedErrors = 5
+
+// stats is what the bots did, shared by all of them.
+type stats struct {
+	// Counters are first so they are 64-bit aligned for atomic access.
+	messagesSent     int64
+	bytesSent        int64
+	messagesReceived int64
+	bytesReceived    int64
+	joined           int64
+	joinFailures     int64
+	disconnects      int64
+	errors           int64
+
+	mu         sync.Mutex
+	loggedErrs int
+}
+
+func (s *stats) sent(size int) {
+	atomic.AddInt64(&s.messagesSent, 1)
+	atomic.AddInt64(&s.bytesSent, int64(size))
+}
+
+func (s *stats) received(size int) {
+	atomic.AddInt64(&s.messagesReceived, 1)
+	atomic.AddInt64(&s.bytesReceived, int64(size))
+}
+
+func (s *stats) join()         { atomic.AddInt64(&s.joined, 1) }
+func (s *stats) disconnected() { atomic.AddInt64(&s.disconnects, 1) }
+
+func (s *stats) joinFailed(err error) {
+	atomic.AddInt64(&s.joinFailures, 1)
+	s.log("join failed: %v", err)
+}
+
+func (s *stats) fail(err error) {
+	atomic.AddInt64(&s.errors, 1)
+	s.log("%v", err)
+}
+
+func (s *stats) log(format string, args ...interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loggedErrs >= maxLoggedErrors {
+		return
+	}
+	s.loggedErrs++
+	log.Printf(format, args...)
+}
+
+// snapshot is a copy of the counters at one point in time.
+type snapshot struct {
+	at                                time.Time
+	messagesSent, bytesSent           int64
+	messagesReceived, bytesReceived   int64
+	joined, joinFailures, disconnects int64
+	errors                            int64
+}
+
+func (s *stats) snapshot(now time.Time) snapshot {
+	s.mu.Lock()
+	s.loggedErrs = 0
+	s.mu.Unlock()
+	return snapshot{
+		at:               now,
+		messagesSent:     atomic.LoadInt64(&s.messagesSent),
+		bytesSent:        atomic.LoadInt64(&s.bytesSent),
+		messagesReceived: atomic.LoadInt64(&s.messagesReceived),
+		bytesReceived:    atomic.LoadInt64(&s.bytesReceived),
+		joined:           atomic.LoadInt64(&s.joined),
+		joinFailures:     atomic.LoadInt64(&s.joinFailures),
+		disconnects:      atomic.LoadInt64(&s.disconnects),
+		errors:           atomic.LoadInt64(&s.errors),
+	}
+}
+
+// report describes what happened between two snapshots. latencies are
+// the round-trip times the server measured to each connected bot.
+func report(prev, cur snapshot, latencies []time.Duration) string {
+	secs := cur.at.Sub(prev.at).Seconds()
+	rate := func(prev, cur int64) float64 { return float64(cur-prev) / secs }
+	return fmt.Sprintf(
+		"bots: %d joined, %d failed, %d dropped | sent: %.0f msg/s, %s/s | received: %.0f msg/s, %s/s | server RTT: %s | errors: %d",
+		cur.joined, cur.joinFailures, cur.disconnects,
+		rate(prev.messagesSent, cur.messagesSent), formatBytes(rate(prev.bytesSent, cur.bytesSent)),
+		rate(prev.messagesReceived, cur.messagesReceived), formatBytes(rate(prev.bytesReceived, cur.bytesReceived)),
+		formatPercentiles(latencies),
+		cur.errors-prev.errors,
+	)
+}
+
+func formatBytes(bytes float64) string {
+	switch {
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1f MB", bytes/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%.1f KB", bytes/(1<<10))
+	}
+	return fmt.Sprintf("%.0f B", bytes)
+}
+
+func formatPercentiles(latencies []time.Duration) string {
+	if len(latencies) == 0 {
+		return "no samples"
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	return fmt.Sprintf("p50 %v, p90 %v, p99 %v, max %v",
+		percentile(latencies, 50), percentile(latencies, 90), percentile(latencies, 99), latencies[len(latencies)-1])
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies.
+func percentile(sorted []time.Duration, pct int) time.Duration {
+	rank := (len(sorted)*pct + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1].Round(100 * time.Microsecond)
+}
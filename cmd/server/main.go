@@ -0,0 +1,91 @@
+// Command server runs a dedicated game server. It doesn't import Ebiten or
+// open a window, so it can run on machines without a display.
+//
+//	go run ./cmd/server --addr :8080 --level default
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/silbinarywolf/networkplatformer-go/game"
+	"github.com/silbinarywolf/networkplatformer-go/gameserver"
+	"github.com/silbinarywolf/networkplatformer-go/level"
+	"github.com/silbinarywolf/networkplatformer-go/netmsg"
+)
+
+// How long the server waits for players to be disconnected cleanly when
+// shutting down.
+const shutdownTimeout = 5 * time.Second
+
+func main() {
+	var (
+		levelName         string
+		moveCheck         = game.MoveCheckCorrect
+		moveTolerance     float64
+		positionPrecision uint
+		options           = gameserver.DefaultOptions()
+	)
+	flag.StringVar(&levelName, "level", "default", "Level the server runs")
+	flag.Var(&moveCheck, "move-check", "What the server does when a player's reported position is too far from its own: off, correct or reject")
+	flag.Float64Var(&moveTolerance, "move-tolerance", 32, "How far in pixels a player's reported position can be from the server's")
+	flag.UintVar(&positionPrecision, "position-precision", netmsg.DefaultPositionPrecision, "Fractional bits that positions are sent with, ie. 4 is 1/16th of a pixel")
+	flag.StringVar(&options.Addr, "addr", options.Addr, "Address the server listens on")
+	flag.StringVar(&options.Path, "path", options.Path, "Path of the websocket endpoint")
+	flag.IntVar(&options.MaxClients, "max-clients", options.MaxClients, "Maximum number of connected players")
+	flag.IntVar(&options.ReadBufferSize, "read-buffer", options.ReadBufferSize, "Websocket read buffer size in bytes")
+	flag.IntVar(&options.WriteBufferSize, "write-buffer", options.WriteBufferSize, "Websocket write buffer size in bytes")
+	flag.IntVar(&options.SendBufferSize, "send-buffer", options.SendBufferSize, "Number of outbound messages queued per player")
+	flag.IntVar(&options.ReceiveBufferSize, "receive-buffer", options.ReceiveBufferSize, "Number of inbound messages queued per player for the game loop")
+	flag.Var(&options.SendPolicy, "send-policy", "What the server does when a player's send buffer is full: drop-oldest, coalesce or disconnect")
+	flag.Int64Var(&options.MaxMessageSize, "max-message-size", options.MaxMessageSize, "Maximum message size accepted from players in bytes")
+	flag.DurationVar(&options.WriteWait, "write-wait", options.WriteWait, "Time allowed to write a message to a player")
+	flag.DurationVar(&options.PongWait, "pong-wait", options.PongWait, "Time allowed to wait for a pong from a player")
+	flag.DurationVar(&options.GracePeriod, "grace-period", options.GracePeriod, "How long players who lose connection stay in the world, waiting to reconnect")
+	flag.DurationVar(&options.NetSim.Latency, "sim-latency", 0, "Simulated delay added to every message sent and received")
+	flag.DurationVar(&options.NetSim.Jitter, "sim-jitter", 0, "Simulated messages are delayed up to this much more or less than -sim-latency")
+	flag.Float64Var(&options.NetSim.Loss, "sim-loss", 0, "Chance of a simulated message being dropped, from 0 to 1")
+	flag.Float64Var(&options.NetSim.Duplicate, "sim-dup", 0, "Chance of a simulated message being delivered twice, from 0 to 1")
+	flag.Float64Var(&options.NetSim.Reorder, "sim-reorder", 0, "Chance of a simulated message overtaking earlier ones, from 0 to 1")
+	flag.Parse()
+
+	if err := options.NetSim.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	if positionPrecision > netmsg.MaxPositionPrecision {
+		log.Fatalf("Position precision can be at most %d bits", netmsg.MaxPositionPrecision)
+	}
+	lvl, err := level.Load(levelName)
+	if err != nil {
+		log.Fatalf("Failed to load level %q: %v", levelName, err)
+	}
+
+	server := game.NewServer(options, lvl)
+	server.MoveCheck = moveCheck
+	server.MoveTolerance = moveTolerance
+	server.Quantizer.Precision = uint32(positionPrecision)
+	log.Printf("Listening on %s%s", options.Addr, options.Path)
+	go server.Listen()
+
+	// Tick until we're told to stop, then disconnect everyone
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	stop := make(chan struct{})
+	go func() {
+		sig := <-interrupt
+		log.Printf("Received %s, shutting down server", sig)
+		close(stop)
+	}()
+	server.Run(stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx, "Server is shutting down"); err != nil {
+		log.Printf("Failed to shut down cleanly: %v", err)
+	}
+}
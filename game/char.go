@@ -0,0 +1,105 @@
+// Package game is the simulation clients and the server share, and the
+// server's authoritative game loop that runs it.
+package game
+
+import (
+	"github.com/silbinarywolf/networkplatformer-go/level"
+)
+
+const (
+	// Physics, in pixels per second
+	moveSpeed    = 180
+	jumpSpeed    = 600
+	gravity      = 1800 // per second, per second
+	maxFallSpeed = 1200
+
+	// Size of a character's collision box, in pixels
+	CharWidth  = 40
+	CharHeight = 64
+)
+
+// Input is the state of a player's controls for one step of the simulation.
+type Input struct {
+	// Increases by one for each input a client sends
+	Sequence uint32
+
+	Left  bool
+	Right bool
+	Jump  bool
+}
+
+// Char is a player's character, simulated the same way by clients and the
+// server.
+type Char struct {
+	X                 float64
+	Y                 float64
+	VX                float64
+	VY                float64
+	IsKeyLeftPressed  bool
+	IsKeyRightPressed bool
+	IsKeyJumpPressed  bool
+
+	// Display name, drawn above the character
+	Name string
+}
+
+// ApplyInput sets the controls used by the next Step.
+func (c *Char) ApplyInput(input Input) {
+	c.IsKeyLeftPressed = input.Left
+	c.IsKeyRightPressed = input.Right
+	c.IsKeyJumpPressed = input.Jump
+}
+
+// IsOnGround reports whether the character is standing on a solid tile
+// and can jump.
+func (c *Char) IsOnGround(lvl *level.Level) bool {
+	return lvl.Collides(c.X, c.Y+1, CharWidth, CharHeight)
+}
+
+// Step moves the character forward by dt seconds based on its inputs and
+// stops it at solid tiles. The client and server both run this with the
+// same dt, so they simulate characters identically.
+func (c *Char) Step(lvl *level.Level, dt float64) {
+	// Walk
+	c.VX = 0
+	if c.IsKeyLeftPressed {
+		c.VX = -moveSpeed
+	} else if c.IsKeyRightPressed {
+		c.VX = moveSpeed
+	}
+
+	// Jump
+	if c.IsKeyJumpPressed && c.IsOnGround(lvl) {
+		c.VY = -jumpSpeed
+	}
+
+	// Fall
+	c.VY += gravity * dt
+	if c.VY > maxFallSpeed {
+		c.VY = maxFallSpeed
+	}
+
+	// Move one axis at a time so characters slide along walls and floors.
+	// Speeds are less than a tile per tick, so if we hit something it's
+	// in the row or column of tiles at our leading edge.
+	c.X += c.VX * dt
+	if c.VX != 0 && lvl.Collides(c.X, c.Y, CharWidth, CharHeight) {
+		left, _, right, _ := level.TileBounds(c.X, c.Y, CharWidth, CharHeight)
+		if c.VX > 0 {
+			c.X = float64(right*level.TileSize) - CharWidth
+		} else {
+			c.X = float64((left + 1) * level.TileSize)
+		}
+		c.VX = 0
+	}
+	c.Y += c.VY * dt
+	if lvl.Collides(c.X, c.Y, CharWidth, CharHeight) {
+		_, top, _, bottom := level.TileBounds(c.X, c.Y, CharWidth, CharHeight)
+		if c.VY > 0 {
+			c.Y = float64(bottom*level.TileSize) - CharHeight
+		} else {
+			c.Y = float64((top + 1) * level.TileSize)
+		}
+		c.VY = 0
+	}
+}
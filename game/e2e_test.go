@@ -0,0 +1,325 @@
+package game
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/silbinarywolf/networkplatformer-go/gameclient"
+	"github.com/silbinarywolf/networkplatformer-go/gameserver"
+	"github.com/silbinarywolf/networkplatformer-go/level"
+	"github.com/silbinarywolf/networkplatformer-go/netmsg"
+	"github.com/silbinarywolf/networkplatformer-go/transport"
+)
+
+// testTimeout is how long tests wait for something to arrive before
+// failing.
+const testTimeout = 5 * time.Second
+
+// startGame runs a server on the default level with its game loop, and
+// serves it on a pipe listener. The returned function stops the game loop
+// and shuts the server down.
+func startGame(t *testing.T, options gameserver.Options) (*Server, *transport.PipeListener, func()) {
+	t.Helper()
+	lvl, err := level.Load("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(options, lvl)
+	l := transport.NewPipeListener()
+	go s.Serve(l)
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		s.Run(stop)
+	}()
+	return s, l, func() {
+		close(stop)
+		<-stopped
+		ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+		defer cancel()
+		if err := s.Shutdown(ctx, "Test finished."); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}
+}
+
+// join connects a client with the given name through dial and returns it
+// with its ConnectResponse.
+func join(t *testing.T, dial func() (transport.Conn, error), name string) (*gameclient.Client, *netmsg.ConnectResponse) {
+	t.Helper()
+	client := gameclient.NewClient()
+	client.SetName(name)
+	if err := client.DialWith(dial); err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	client.Listen()
+	res := receive(t, client, &netmsg.ConnectResponse{}).(*netmsg.ConnectResponse)
+	return client, res
+}
+
+// receive decodes the next message the client gets, skipping anything
+// that isn't of the same type as want.
+func receive(t *testing.T, client *gameclient.Client, want netmsg.Message) netmsg.Message {
+	t.Helper()
+	wantKind, _ := netmsg.KindOf(want)
+	timeout := time.After(testTimeout)
+	for {
+		select {
+		case buf := <-client.ChRecv():
+			kind, msg, err := netmsg.Decode(buf)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if kind == wantKind {
+				return msg
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s", wantKind)
+			return nil
+		}
+	}
+}
+
+// receiveSnapshot returns the players in the first snapshot the client
+// gets that ok accepts. Clients in these tests never acknowledge
+// snapshots, so every snapshot is a full one.
+func receiveSnapshot(t *testing.T, client *gameclient.Client, ok func(netmsg.Players) bool) netmsg.Players {
+	t.Helper()
+	deadline := time.Now().Add(testTimeout)
+	for time.Now().Before(deadline) {
+		snapshot := receive(t, client, &netmsg.WorldSnapshot{}).(*netmsg.WorldSnapshot)
+		players := netmsg.ApplyDeltaSnapshot(nil, snapshot)
+		if ok(players) {
+			return players
+		}
+	}
+	t.Fatal("timed out waiting for snapshot")
+	return nil
+}
+
+func TestJoinAssignsSlotsAndNames(t *testing.T) {
+	_, l, shutdown := startGame(t, gameserver.Options{MaxClients: 8})
+	defer shutdown()
+
+	names := make(map[string]bool)
+	for i := int32(0); i < 3; i++ {
+		client, res := join(t, l.Dial, "Bob")
+		defer client.Close()
+		if res.ClientSlot != i {
+			t.Fatalf("client %d: got slot %d", i, res.ClientSlot)
+		}
+		if res.MaxClients != 8 || res.Level != "default" {
+			t.Fatalf("client %d: got %d max clients on %q, want 8 on \"default\"", i, res.MaxClients, res.Level)
+		}
+		if !strings.HasPrefix(res.Name, "Bob") || names[res.Name] {
+			t.Fatalf("client %d: got name %q, want a new name starting with Bob", i, res.Name)
+		}
+		names[res.Name] = true
+	}
+}
+
+func TestJoinOverWebsocket(t *testing.T) {
+	s, _, shutdown := startGame(t, gameserver.Options{})
+	defer shutdown()
+	httpServer := httptest.NewServer(s.Handler())
+	defer httpServer.Close()
+
+	client := gameclient.NewClient()
+	if err := client.Dial(strings.TrimPrefix(httpServer.URL, "http://")); err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer client.Close()
+	client.Listen()
+	if res := receive(t, client, &netmsg.ConnectResponse{}).(*netmsg.ConnectResponse); res.ClientSlot != 0 {
+		t.Fatalf("got slot %d, want 0", res.ClientSlot)
+	}
+	receive(t, client, &netmsg.WorldSnapshot{})
+}
+
+func TestPlayersSeeEachOtherMove(t *testing.T) {
+	_, l, shutdown := startGame(t, gameserver.Options{})
+	defer shutdown()
+
+	watcher, _ := join(t, l.Dial, "Alice")
+	defer watcher.Close()
+	mover, res := join(t, l.Dial, "Bob")
+	defer mover.Close()
+
+	joined := receive(t, watcher, &netmsg.PlayerJoined{}).(*netmsg.PlayerJoined)
+	if joined.ClientSlot != res.ClientSlot || joined.Name != res.Name {
+		t.Fatalf("got %d %q joining, want %d %q", joined.ClientSlot, joined.Name, res.ClientSlot, res.Name)
+	}
+	receiveSnapshot(t, watcher, func(players netmsg.Players) bool {
+		return len(players) == 2
+	})
+
+	// Hold right, the server simulates it and everyone sees it
+	for sequence := uint32(1); sequence <= 10; sequence++ {
+		packetData, err := netmsg.Encode(&netmsg.UpdatePlayer{
+			InputSequence:     sequence,
+			X:                 res.X,
+			Y:                 res.Y,
+			IsKeyRightPressed: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		mover.SendMessage(packetData)
+	}
+	receiveSnapshot(t, watcher, func(players netmsg.Players) bool {
+		state := players[res.ClientSlot]
+		return state != nil && state.IsKeyRightPressed && state.X > res.X
+	})
+}
+
+func TestLostPlayersLeaveAfterGracePeriod(t *testing.T) {
+	_, l, shutdown := startGame(t, gameserver.Options{
+		MaxClients:  2,
+		GracePeriod: 100 * time.Millisecond,
+	})
+	defer shutdown()
+
+	watcher, _ := join(t, l.Dial, "Alice")
+	defer watcher.Close()
+	leaver, res := join(t, l.Dial, "Bob")
+	leaver.Close()
+
+	// They stay in the world for a while, marked as interrupted
+	receiveSnapshot(t, watcher, func(players netmsg.Players) bool {
+		state := players[res.ClientSlot]
+		return state != nil && state.ConnectionInterrupted
+	})
+	left := receive(t, watcher, &netmsg.DisconnectPlayer{}).(*netmsg.DisconnectPlayer)
+	if left.ClientSlot != res.ClientSlot {
+		t.Fatalf("got slot %d leaving, want %d", left.ClientSlot, res.ClientSlot)
+	}
+
+	// Their slot is free for someone else
+	client, joined := join(t, l.Dial, "Carol")
+	defer client.Close()
+	if joined.ClientSlot != res.ClientSlot {
+		t.Fatalf("got slot %d, want the freed slot %d", joined.ClientSlot, res.ClientSlot)
+	}
+}
+
+func TestReconnectKeepsPlayer(t *testing.T) {
+	_, l, shutdown := startGame(t, gameserver.Options{})
+	defer shutdown()
+
+	watcher, _ := join(t, l.Dial, "Alice")
+	defer watcher.Close()
+
+	var (
+		mu   sync.Mutex
+		conn transport.Conn
+	)
+	client := gameclient.NewClient()
+	client.SetName("Bob")
+	client.SetReconnect(true)
+	err := client.DialWith(func() (transport.Conn, error) {
+		c, err := l.Dial()
+		mu.Lock()
+		conn = c
+		mu.Unlock()
+		return c, err
+	})
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	client.Listen()
+	defer client.Close()
+	res := receive(t, client, &netmsg.ConnectResponse{}).(*netmsg.ConnectResponse)
+
+	// Everyone else sees them frozen until they are back
+	mu.Lock()
+	conn.Close()
+	mu.Unlock()
+	receiveSnapshot(t, watcher, func(players netmsg.Players) bool {
+		state := players[res.ClientSlot]
+		return state != nil && state.ConnectionInterrupted
+	})
+	resumed := receive(t, client, &netmsg.ConnectResponse{}).(*netmsg.ConnectResponse)
+	if resumed.ClientSlot != res.ClientSlot || resumed.Name != res.Name {
+		t.Fatalf("got %d %q after reconnecting, want %d %q", resumed.ClientSlot, resumed.Name, res.ClientSlot, res.Name)
+	}
+	receiveSnapshot(t, watcher, func(players netmsg.Players) bool {
+		state := players[res.ClientSlot]
+		return state != nil && !state.ConnectionInterrupted
+	})
+}
+
+// sendInputs sends an input every tick for seconds, like the game does,
+// and calls check with the newest input the server said it processed each
+// time a snapshot arrives.
+func sendInputs(t *testing.T, client *gameclient.Client, res *netmsg.ConnectResponse, seconds int, check func(sent, acked uint32)) {
+	t.Helper()
+	ticker := time.NewTicker(TickDuration)
+	defer ticker.Stop()
+	var sent, acked uint32
+	for sent < uint32(seconds*TickRate) {
+		select {
+		case <-ticker.C:
+			sent++
+			packetData, err := netmsg.Encode(&netmsg.UpdatePlayer{
+				InputSequence: sent,
+				X:             res.X,
+				Y:             res.Y,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			client.SendMessage(packetData)
+		case buf := <-client.ChRecv():
+			_, msg, err := netmsg.Decode(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if snapshot, ok := msg.(*netmsg.WorldSnapshot); ok && snapshot.InputSequence > acked {
+				acked = snapshot.InputSequence
+			}
+			check(sent, acked)
+		}
+	}
+}
+
+func TestServerKeepsUpWithInputs(t *testing.T) {
+	_, l, shutdown := startGame(t, gameserver.Options{})
+	defer shutdown()
+
+	client, res := join(t, l.Dial, "Bob")
+	defer client.Close()
+
+	// Inputs are processed as they arrive rather than piling up
+	const maxBacklog = 10
+	sendInputs(t, client, res, 3, func(sent, acked uint32) {
+		if sent > maxBacklog && sent-acked > maxBacklog {
+			t.Fatalf("%d inputs sent but only %d processed", sent, acked)
+		}
+	})
+}
+
+func TestRTTStaysFlatWhileSendingInputs(t *testing.T) {
+	_, l, shutdown := startGame(t, gameserver.Options{PingInterval: 50 * time.Millisecond})
+	defer shutdown()
+
+	client, res := join(t, l.Dial, "Bob")
+	defer client.Close()
+
+	// Pongs aren't held up behind inputs, so the server measures the
+	// pipe, which takes next to no time
+	const maxRTT = 50 * time.Millisecond
+	sendInputs(t, client, res, 3, func(sent, acked uint32) {
+		if rtt := client.ServerRTT(); rtt > maxRTT {
+			t.Fatalf("server measured %v after %d inputs, want at most %v", rtt, sent, maxRTT)
+		}
+	})
+	if client.ServerRTT() == 0 {
+		t.Fatal("server didn't measure the round-trip time")
+	}
+}
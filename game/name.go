@@ -0,0 +1,84 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/silbinarywolf/networkplatformer-go/gameserver"
+)
+
+const (
+	// MaxNameLength is the most characters a player's name can have.
+	MaxNameLength = 16
+
+	// MaxChatLength is the most characters a chat message can have,
+	// longer messages are cut.
+	MaxChatLength = 100
+)
+
+// IsPrintable reports whether r is printable ASCII, which is all the font
+// used for names and chat can draw.
+func IsPrintable(r rune) bool {
+	return r >= ' ' && r <= '~'
+}
+
+// stripUnprintable removes every character IsPrintable rejects.
+func stripUnprintable(s string) string {
+	return strings.Map(func(r rune) rune {
+		if !IsPrintable(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// SanitizeName makes a name safe to draw for other players. Only printable
+// characters are kept, runs of spaces are collapsed and it is cut to
+// MaxNameLength characters.
+func SanitizeName(name string) string {
+	name = strings.Join(strings.Fields(stripUnprintable(name)), " ")
+	if len(name) > MaxNameLength {
+		name = strings.TrimSpace(name[:MaxNameLength])
+	}
+	return name
+}
+
+// SanitizeChat makes a chat message safe to draw for other players. Only
+// printable characters are kept and it is cut to MaxChatLength characters.
+func SanitizeChat(text string) string {
+	text = strings.TrimSpace(stripUnprintable(text))
+	if len(text) > MaxChatLength {
+		text = strings.TrimSpace(text[:MaxChatLength])
+	}
+	return text
+}
+
+// uniqueName returns a name for the client that no other player has,
+// including players waiting to reconnect, ignoring case. Numbers are added
+// to the end of names already in use, and players who didn't give a name
+// are named after their slot.
+func (s *Server) uniqueName(client *gameserver.Client, name string) string {
+	if name == "" {
+		name = fmt.Sprintf("Player %d", client.ClientSlot()+1)
+	}
+	taken := make(map[string]bool, len(s.GetClients()))
+	for otherClient := range s.GetClients() {
+		if otherClient == client {
+			continue
+		}
+		taken[strings.ToLower(otherClient.Data().(*player).Name)] = true
+	}
+	for _, char := range s.interrupted {
+		taken[strings.ToLower(char.Name)] = true
+	}
+	unique := name
+	for i := 2; taken[strings.ToLower(unique)]; i++ {
+		suffix := fmt.Sprintf(" %d", i)
+		base := name
+		if len(base)+len(suffix) > MaxNameLength {
+			base = strings.TrimSpace(base[:MaxNameLength-len(suffix)])
+		}
+		unique = base + suffix
+	}
+	return unique
+}
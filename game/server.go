@@ -0,0 +1,530 @@
+package game
+
+import (
+	"errors"
+	"log"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/silbinarywolf/networkplatformer-go/gameserver"
+	"github.com/silbinarywolf/networkplatformer-go/level"
+	"github.com/silbinarywolf/networkplatformer-go/netmsg"
+)
+
+// MoveCheck is what the server does when a client reports a position that
+// is further than the tolerance from where the server simulated them.
+type MoveCheck int
+
+const (
+	// Reported positions are ignored
+	MoveCheckOff MoveCheck = iota
+	// The position is logged and the client is corrected by the
+	// server's state in the next snapshot
+	MoveCheckCorrect
+	// As with MoveCheckCorrect, but the inputs in the client's message
+	// are discarded too
+	MoveCheckReject
+)
+
+var moveCheckToString = []string{
+	MoveCheckOff:     "off",
+	MoveCheckCorrect: "correct",
+	MoveCheckReject:  "reject",
+}
+
+var ErrUnknownMoveCheck = errors.New("Unknown move check, expected off, correct or reject.")
+
+func (m MoveCheck) String() string {
+	if int(m) >= 0 && int(m) < len(moveCheckToString) {
+		return moveCheckToString[m]
+	}
+	return "unknown"
+}
+
+// Set parses the move check from a string, so it can be used as a flag.
+func (m *MoveCheck) Set(value string) error {
+	for i, name := range moveCheckToString {
+		if name == value {
+			*m = MoveCheck(i)
+			return nil
+		}
+	}
+	return ErrUnknownMoveCheck
+}
+
+const (
+	// Most inputs that can be waiting to be processed for a player,
+	// any more are dropped.
+	maxQueuedInputs = 32
+
+	// Most steps a player can save up by not sending inputs. This lets a
+	// player catch up after their inputs are delayed without letting them
+	// move faster than everyone else by sending more inputs.
+	maxInputCredit = 8
+
+	// Players can send chatBurst messages at once, after that they can
+	// send one every chatInterval ticks.
+	chatBurst    = 5
+	chatInterval = 2 * TickRate
+
+	// Ticks between logging the same warning about a player, as they
+	// can cause most of them with every message.
+	warningInterval = TickRate
+)
+
+// queuedInput is an input received from a client that the server hasn't
+// simulated yet.
+type queuedInput struct {
+	Input
+
+	// Where the client was before applying this input
+	X float64
+	Y float64
+}
+
+// player is a player's character along with what the server keeps track
+// of for them.
+type player struct {
+	Char
+
+	lastInputSequence uint32
+	lastAckedSnapshot uint64
+	inputs            []queuedInput
+	inputCredit       int
+	chatTime          uint64
+
+	// Steps taken with the held controls because no input had arrived,
+	// and the state before them, so they can be redone once the inputs
+	// turn up.
+	extrapolated        int
+	beforeExtrapolating Char
+
+	// Positions over MoveTolerance and inputs dropped for being sent too
+	// fast
+	moveWarning  warning
+	inputWarning warning
+}
+
+// warning is a log message about a player, logged at most once every
+// warningInterval ticks.
+type warning struct {
+	// Times it happened since it was last logged
+	count int
+
+	// Tick it can next be logged at
+	next uint64
+}
+
+// logf logs the warning unless it was logged recently, along with how
+// many times it happened since.
+func (w *warning) logf(tick uint64, format string, args ...interface{}) {
+	w.count++
+	if tick < w.next {
+		return
+	}
+	log.Printf(format+", %d times since last logged", append(args, w.count)...)
+	w.count = 0
+	w.next = tick + warningInterval
+}
+
+// Server runs the game for the players connected to a gameserver.Server.
+// It simulates every player, so it has the final say on where they are.
+type Server struct {
+	*gameserver.Server
+
+	// The level being played, clients are told which one when they
+	// connect
+	level *level.Level
+
+	// Simulation steps run so far
+	tick uint64
+
+	// What to do when a client reports a position too far from ours
+	MoveCheck MoveCheck
+
+	// How far in pixels a client reported position can be from the
+	// server's before MoveCheck applies
+	MoveTolerance float64
+
+	// Recent snapshots, used as baselines for delta snapshots
+	snapshots netmsg.SnapshotHistory
+
+	// Encodes positions and velocities sent to clients
+	Quantizer netmsg.Quantizer
+
+	// Routes messages from clients to the handle* methods
+	dispatcher netmsg.Dispatcher
+
+	// Players who lost connection, by client slot. Their character stays
+	// frozen in the world until they reconnect or the grace period ends.
+	interrupted map[int32]*player
+}
+
+// NewServer creates a server that runs the given level.
+func NewServer(options gameserver.Options, lvl *level.Level) *Server {
+	server := &Server{
+		Server:        gameserver.NewServer(options),
+		level:         lvl,
+		MoveCheck:     MoveCheckCorrect,
+		MoveTolerance: 32,
+		Quantizer: netmsg.Quantizer{
+			Precision: netmsg.DefaultPositionPrecision,
+		},
+		interrupted: make(map[int32]*player),
+	}
+	server.dispatcher.Handle(server.handleUpdatePlayer)
+	server.dispatcher.Handle(server.handleChatSend)
+	return server
+}
+
+// Run ticks the game at TickRate until stop is closed. The game loop
+// services ChRegister, ChUnregister and ChBroadcast, so Shutdown should be
+// called once Run has returned.
+func (s *Server) Run(stop <-chan struct{}) {
+	var ticks TickAccumulator
+	ticker := time.NewTicker(TickDuration)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			// The ticker drops ticks if we fall behind, so count how many
+			// are due instead of assuming one.
+			for n := ticks.Ticks(now); n > 0; n-- {
+				s.Update()
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Update handles what players sent since the last tick, simulates one tick
+// and sends everyone a snapshot of the world.
+func (s *Server) Update() {
+RecvMsgLoop:
+	for {
+		select {
+		case client := <-s.ChRegister():
+			clientSlot := int32(client.ClientSlot())
+
+			var char *player
+			if client.Resumed() {
+				// They reconnected in time, so carry on with the same
+				// player. The client starts counting inputs and snapshots
+				// from scratch on a new connection.
+				char = client.Data().(*player)
+				char.lastInputSequence = 0
+				char.lastAckedSnapshot = 0
+				char.inputs = char.inputs[:0]
+				char.extrapolated = 0
+				delete(s.interrupted, clientSlot)
+			} else {
+				// Create player instance at a random spawn point
+				spawn := s.level.Spawns[rand.Intn(len(s.level.Spawns))]
+				char = &player{
+					Char: Char{
+						X:    spawn.X,
+						Y:    spawn.Y - CharHeight,
+						Name: s.uniqueName(client, SanitizeName(client.Hello().Name)),
+					},
+				}
+			}
+
+			// Create client
+			s.RegisterClient(client, char)
+
+			// Send connecting player their information
+			s.send(client, &netmsg.ConnectResponse{
+				ClientSlot:        clientSlot,
+				X:                 s.Quantizer.Encode(char.X),
+				Y:                 s.Quantizer.Encode(char.Y),
+				MaxClients:        s.GetMaxClients(),
+				Level:             s.level.Name,
+				Tick:              s.tick,
+				PositionPrecision: s.Quantizer.Precision,
+				Name:              char.Name,
+				ResumeToken:       client.ResumeToken(),
+			})
+
+			// Tell everyone else who joined, and the new player who is
+			// already here.
+			joined, err := netmsg.Encode(&netmsg.PlayerJoined{
+				ClientSlot: clientSlot,
+				Name:       char.Name,
+			})
+			if err != nil {
+				log.Printf("client #%d join: %v", clientSlot, err)
+				break
+			}
+			for otherClient := range s.GetClients() {
+				if otherClient == client {
+					continue
+				}
+				otherClient.SendMessage(joined)
+				s.send(client, &netmsg.PlayerJoined{
+					ClientSlot: otherClient.ClientSlot(),
+					Name:       otherClient.Data().(*player).Name,
+				})
+			}
+			for otherSlot, otherChar := range s.interrupted {
+				s.send(client, &netmsg.PlayerJoined{
+					ClientSlot: otherSlot,
+					Name:       otherChar.Name,
+				})
+			}
+			if client.Resumed() {
+				log.Printf("client #%d reconnected as %q", clientSlot, char.Name)
+			} else {
+				log.Printf("client #%d joined as %q", clientSlot, char.Name)
+			}
+		case client := <-s.ChUnregister():
+			// Keep their slot and freeze their character for a while in
+			// case they reconnect
+			if s.SuspendClient(client) {
+				char := client.Data().(*player)
+				char.VX = 0
+				char.VY = 0
+				char.IsKeyLeftPressed = false
+				char.IsKeyRightPressed = false
+				char.IsKeyJumpPressed = false
+				char.inputs = char.inputs[:0]
+				char.extrapolated = 0
+				s.interrupted[client.ClientSlot()] = char
+
+				log.Printf("client #%d lost connection, %d messages to them were dropped", client.ClientSlot(), client.Dropped())
+			}
+		case message := <-s.ChBroadcast():
+			var (
+				client = message.Client()
+				buf    = message.Data()
+			)
+			if err := s.dispatcher.Dispatch(buf, client); err != nil {
+				log.Printf("client #%d sent a bad message: %v", client.ClientSlot(), err)
+			}
+		default:
+			// no-op
+			break RecvMsgLoop
+		}
+	}
+
+	for _, client := range s.ExpireSessions() {
+		s.removePlayer(client)
+	}
+
+	// Send everyone the state of the world
+	s.sendSnapshots()
+
+	s.processInputs()
+
+	s.tick++
+	s.SetTick(s.tick)
+}
+
+// removePlayer takes a player who didn't reconnect within the grace period
+// out of the world and tells everyone they left.
+func (s *Server) removePlayer(client *gameserver.Client) {
+	delete(s.interrupted, client.ClientSlot())
+
+	log.Printf("client #%d disconnected", client.ClientSlot())
+
+	// Tell clients player disconnected
+	packetData, err := netmsg.Encode(&netmsg.DisconnectPlayer{
+		ClientSlot: client.ClientSlot(),
+	})
+	if err != nil {
+		log.Printf("client #%d disconnect: %v", client.ClientSlot(), err)
+		return
+	}
+	for otherClient := range s.GetClients() {
+		otherClient.SendMessage(packetData)
+	}
+}
+
+// handleUpdatePlayer queues a client's input to be simulated by
+// processInputs.
+func (s *Server) handleUpdatePlayer(client *gameserver.Client, recvMsg *netmsg.UpdatePlayer) {
+	char := client.Data().(*player)
+	if recvMsg.SnapshotAck > char.lastAckedSnapshot {
+		char.lastAckedSnapshot = recvMsg.SnapshotAck
+	}
+	lastSequence := char.lastInputSequence
+	if len(char.inputs) > 0 {
+		lastSequence = char.inputs[len(char.inputs)-1].Sequence
+	}
+	if recvMsg.InputSequence <= lastSequence {
+		// Old input, ignore
+		return
+	}
+	if len(char.inputs) >= maxQueuedInputs {
+		char.inputWarning.logf(s.tick, "client #%d is sending inputs too fast, dropping input", client.ClientSlot())
+		return
+	}
+	char.inputs = append(char.inputs, queuedInput{
+		Input: Input{
+			Sequence: recvMsg.InputSequence,
+			Left:     recvMsg.IsKeyLeftPressed,
+			Right:    recvMsg.IsKeyRightPressed,
+			Jump:     recvMsg.IsKeyJumpPressed,
+		},
+		X: s.Quantizer.Decode(recvMsg.X),
+		Y: s.Quantizer.Decode(recvMsg.Y),
+	})
+}
+
+// handleChatSend passes a chat message on to everyone, as long as the
+// player isn't sending them too fast.
+func (s *Server) handleChatSend(client *gameserver.Client, recvMsg *netmsg.ChatSend) {
+	text := SanitizeChat(recvMsg.Text)
+	if text == "" {
+		return
+	}
+
+	// chatTime is when the player will have used up their allowance, if it
+	// is too far ahead they have sent a burst of messages recently.
+	char := client.Data().(*player)
+	if char.chatTime < s.tick {
+		char.chatTime = s.tick
+	}
+	if char.chatTime-s.tick >= chatBurst*chatInterval {
+		s.send(client, &netmsg.ChatMessage{
+			ClientSlot: -1,
+			Text:       "You are sending messages too fast.",
+		})
+		return
+	}
+	char.chatTime += chatInterval
+
+	log.Printf("chat: %s: %s", char.Name, text)
+	packetData, err := netmsg.Encode(&netmsg.ChatMessage{
+		ClientSlot: client.ClientSlot(),
+		Name:       char.Name,
+		Text:       text,
+	})
+	if err != nil {
+		log.Printf("client #%d chat: %v", client.ClientSlot(), err)
+		return
+	}
+	for otherClient := range s.GetClients() {
+		otherClient.SendMessage(packetData)
+	}
+}
+
+// send encodes msg and sends it to client.
+func (s *Server) send(client *gameserver.Client, msg netmsg.Message) {
+	packetData, err := netmsg.Encode(msg)
+	if err != nil {
+		log.Printf("client #%d: failed to encode message: %v", client.ClientSlot(), err)
+		return
+	}
+	client.SendMessage(packetData)
+}
+
+// processInputs steps each player's character once for every input they
+// have sent, the same way the client predicted it. Players get one step of
+// credit each tick, so sending inputs faster doesn't make them faster.
+//
+// Players whose inputs are late keep moving with the controls they last
+// held, so they don't freeze for everyone else. If the inputs arrive within
+// maxInputCredit ticks those steps are redone with them, otherwise the
+// guessed steps stand and the late inputs are dropped.
+func (s *Server) processInputs() {
+	for client := range s.GetClients() {
+		char := client.Data().(*player)
+		if char.inputCredit < maxInputCredit {
+			char.inputCredit++
+		}
+		if len(char.inputs) > 0 && char.extrapolated > 0 {
+			if char.extrapolated <= maxInputCredit {
+				char.Char = char.beforeExtrapolating
+			} else {
+				// Everyone has seen where the guessed steps took them,
+				// so acknowledge the inputs without applying them
+				char.lastInputSequence = char.inputs[len(char.inputs)-1].Sequence
+				char.inputs = char.inputs[:0]
+			}
+			char.extrapolated = 0
+		}
+		if len(char.inputs) == 0 {
+			if char.extrapolated == 0 {
+				char.beforeExtrapolating = char.Char
+			}
+			char.extrapolated++
+			char.Step(s.level, TickDuration.Seconds())
+			continue
+		}
+		for len(char.inputs) > 0 && char.inputCredit > 0 {
+			input := char.inputs[0]
+			copy(char.inputs, char.inputs[1:])
+			char.inputs = char.inputs[:len(char.inputs)-1]
+			char.inputCredit--
+			char.lastInputSequence = input.Sequence
+
+			// The server runs the simulation, so the client's position is
+			// only used to check they are where their inputs allow. Either
+			// way the client is corrected by the next snapshot.
+			if s.MoveCheck != MoveCheckOff {
+				dist := math.Hypot(input.X-char.X, input.Y-char.Y)
+				if dist > s.MoveTolerance {
+					char.moveWarning.logf(s.tick, "client #%d position is %.1fpx from server (%s)", client.ClientSlot(), dist, s.MoveCheck)
+					if s.MoveCheck == MoveCheckReject {
+						// Acknowledge the input without applying it, so the
+						// client doesn't replay it when it corrects itself.
+						continue
+					}
+				}
+			}
+
+			char.ApplyInput(input.Input)
+			char.Step(s.level, TickDuration.Seconds())
+		}
+	}
+}
+
+// playerState is the full state of a player, to be sent in snapshots.
+func (s *Server) playerState(clientSlot int32, char *player) *netmsg.PlayerState {
+	return &netmsg.PlayerState{
+		ClientSlot:        clientSlot,
+		X:                 s.Quantizer.Encode(char.X),
+		Y:                 s.Quantizer.Encode(char.Y),
+		VX:                s.Quantizer.Encode(char.VX),
+		VY:                s.Quantizer.Encode(char.VY),
+		IsKeyLeftPressed:  char.IsKeyLeftPressed,
+		IsKeyRightPressed: char.IsKeyRightPressed,
+		IsKeyJumpPressed:  char.IsKeyJumpPressed,
+		Changed:           netmsg.FieldAll,
+	}
+}
+
+// sendSnapshots sends every client a snapshot of the world. Each client's
+// snapshot only has what changed since the last snapshot they acknowledged,
+// so idle players cost next to nothing.
+func (s *Server) sendSnapshots() {
+	clients := s.GetClients()
+	players := make(netmsg.Players, len(clients)+len(s.interrupted))
+	for client := range clients {
+		players[client.ClientSlot()] = s.playerState(client.ClientSlot(), client.Data().(*player))
+	}
+	for clientSlot, char := range s.interrupted {
+		state := s.playerState(clientSlot, char)
+		state.ConnectionInterrupted = true
+		players[clientSlot] = state
+	}
+	s.snapshots.Add(s.tick, players)
+
+	for client := range clients {
+		char := client.Data().(*player)
+
+		// Fall back to a full snapshot if we no longer have the one they
+		// acknowledged.
+		baselineTick := char.lastAckedSnapshot
+		baseline, ok := s.snapshots.Get(baselineTick)
+		if !ok {
+			baselineTick = 0
+		}
+		sendMsg := netmsg.NewDeltaSnapshot(s.tick, baselineTick, baseline, players)
+		sendMsg.InputSequence = char.lastInputSequence
+
+		s.send(client, sendMsg)
+	}
+}
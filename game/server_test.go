@@ -0,0 +1,151 @@
+package game
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/silbinarywolf/networkplatformer-go/gameserver"
+	"github.com/silbinarywolf/networkplatformer-go/level"
+	"github.com/silbinarywolf/networkplatformer-go/netmsg"
+)
+
+// newTestPlayer registers a player standing on the level's first spawn
+// with a client that isn't connected to anything.
+func newTestPlayer(t *testing.T, s *Server) *player {
+	t.Helper()
+	spawn := s.level.Spawns[0]
+	char := &player{
+		Char: Char{
+			X: spawn.X,
+			Y: spawn.Y - CharHeight,
+		},
+	}
+	s.RegisterClient(&gameserver.Client{}, char)
+	return char
+}
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	lvl, err := level.Load("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewServer(gameserver.Options{}, lvl)
+}
+
+func queueInput(char *player, input Input) {
+	char.inputs = append(char.inputs, queuedInput{
+		Input: input,
+		X:     char.X,
+		Y:     char.Y,
+	})
+}
+
+func TestLateInputsKeepPlayersMoving(t *testing.T) {
+	s := newTestServer(t)
+	s.MoveCheck = MoveCheckOff
+	char := newTestPlayer(t, s)
+	want := char.Char
+
+	// They were holding right, so keep going right without inputs
+	queueInput(char, Input{Sequence: 1, Right: true})
+	s.processInputs()
+	want.ApplyInput(Input{Right: true})
+	want.Step(s.level, TickDuration.Seconds())
+	for i := 0; i < 3; i++ {
+		s.processInputs()
+	}
+	if char.X <= want.X {
+		t.Fatalf("got x %v, want more than %v", char.X, want.X)
+	}
+
+	// Once their inputs turn up, the guessed steps are redone with them
+	inputs := []Input{
+		{Sequence: 2, Left: true},
+		{Sequence: 3, Left: true},
+		{Sequence: 4},
+		{Sequence: 5, Jump: true},
+	}
+	for _, input := range inputs {
+		queueInput(char, input)
+		want.ApplyInput(input)
+		want.Step(s.level, TickDuration.Seconds())
+	}
+	s.processInputs()
+	if char.Char != want {
+		t.Fatalf("got %+v, want %+v", char.Char, want)
+	}
+	if char.lastInputSequence != 5 {
+		t.Fatalf("got input %d processed, want 5", char.lastInputSequence)
+	}
+}
+
+func TestVeryLateInputsAreDropped(t *testing.T) {
+	s := newTestServer(t)
+	s.MoveCheck = MoveCheckOff
+	char := newTestPlayer(t, s)
+
+	queueInput(char, Input{Sequence: 1, Right: true})
+	s.processInputs()
+	for i := 0; i < maxInputCredit+1; i++ {
+		s.processInputs()
+	}
+	guessed := char.Char
+
+	// Too late to redo the guessed steps, so they stand
+	queueInput(char, Input{Sequence: 2, Left: true})
+	queueInput(char, Input{Sequence: 3, Left: true})
+	want := guessed
+	want.Step(s.level, TickDuration.Seconds())
+	s.processInputs()
+	if char.Char != want {
+		t.Fatalf("got %+v, want %+v", char.Char, want)
+	}
+	if char.lastInputSequence != 3 || len(char.inputs) != 0 {
+		t.Fatalf("got input %d processed and %d queued, want 3 and none", char.lastInputSequence, len(char.inputs))
+	}
+}
+
+func TestWarningsAreRateLimited(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	s := newTestServer(t)
+	char := newTestPlayer(t, s)
+
+	// Every input is too far off for two seconds
+	for i := 0; i < 2*warningInterval; i++ {
+		char.inputs = append(char.inputs, queuedInput{
+			Input: Input{Sequence: uint32(i + 1)},
+			X:     char.X + 2*s.MoveTolerance,
+			Y:     char.Y,
+		})
+		s.processInputs()
+		s.tick++
+	}
+	if lines := strings.Count(buf.String(), "from server"); lines != 2 {
+		t.Fatalf("got %d warnings logged, want 2:\n%s", lines, buf.String())
+	}
+	if !strings.Contains(buf.String(), fmt.Sprintf("%d times since last logged", warningInterval)) {
+		t.Fatalf("second warning doesn't count the ones in between:\n%s", buf.String())
+	}
+
+	// Dropped inputs are limited the same way
+	buf.Reset()
+	for client := range s.GetClients() {
+		for i := 0; i < maxQueuedInputs+2*warningInterval; i++ {
+			s.handleUpdatePlayer(client, &netmsg.UpdatePlayer{InputSequence: uint32(1000 + i)})
+			if i >= maxQueuedInputs {
+				s.tick++
+			}
+		}
+	}
+	if lines := strings.Count(buf.String(), "too fast"); lines != 2 {
+		t.Fatalf("got %d warnings logged, want 2:\n%s", lines, buf.String())
+	}
+}
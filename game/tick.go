@@ -0,0 +1,42 @@
+package game
+
+import (
+	"time"
+)
+
+const (
+	// Simulation steps per second, on both clients and the server
+	TickRate = 60
+
+	// Length of one simulation step
+	TickDuration = time.Second / TickRate
+
+	// Most steps simulated at once when catching up after a stall. Any
+	// time past this is dropped, so a long stall doesn't lock up the game
+	// while it catches up.
+	maxTicksPerUpdate = 8
+)
+
+// TickAccumulator works out how many fixed-length ticks to simulate for the
+// real time that has passed.
+type TickAccumulator struct {
+	last        time.Time
+	accumulated time.Duration
+}
+
+// Ticks returns how many ticks are due at now. Time that doesn't add up to
+// a full tick carries over to the next call.
+func (a *TickAccumulator) Ticks(now time.Time) int {
+	if a.last.IsZero() {
+		a.last = now
+		return 1
+	}
+	a.accumulated += now.Sub(a.last)
+	a.last = now
+	ticks := int(a.accumulated / TickDuration)
+	a.accumulated -= time.Duration(ticks) * TickDuration
+	if ticks > maxTicksPerUpdate {
+		ticks = maxTicksPerUpdate
+	}
+	return ticks
+}
@@ -1,6 +1,20 @@
 package gameclient
 
-import "time"
+import (
+	"errors"
+	"log"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/silbinarywolf/networkplatformer-go/latency"
+	"github.com/silbinarywolf/networkplatformer-go/netmsg"
+	"github.com/silbinarywolf/networkplatformer-go/netsim"
+	"github.com/silbinarywolf/networkplatformer-go/transport"
+)
 
 const (
 	// Time allowed to write a message to the peer.
@@ -12,25 +26,385 @@ const (
 	// Send pings to peer with this period. Must be less than pongWait.
 	pingPeriod = (pongWait * 9) / 10
 
-	// Maximum message size allowed from peer.
-	maxMessageSize = 512
+	// Path of the websocket endpoint on the server, unless changed with SetPath.
+	defaultPath = "/ws"
+
+	// Time allowed for the server to reply to Hello.
+	handshakeWait = 10 * time.Second
+
+	// Time waited before the first reconnect attempt. It doubles after
+	// each failed attempt, up to maxReconnectDelay.
+	minReconnectDelay = 250 * time.Millisecond
+	maxReconnectDelay = 8 * time.Second
+
+	// How often the server is pinged to measure round-trip time.
+	pingInterval = 1 * time.Second
+
+	// Most bytes of the name sent in Hello. The server shortens names
+	// further, this keeps Hello under the size it accepts.
+	maxNameSize = 64
 )
 
+var (
+	ErrClosed = errors.New("Client has been closed.")
+)
+
+// RejectError is returned by Dial, DialTLS and DialWith when the server refuses the
+// connection, such as when the client is too old.
+type RejectError struct {
+	Reason string
+
+	// The protocol version the server speaks
+	ProtocolVersion uint32
+
+	// Close code the server sent after the Reject, or 0 if it didn't
+	Code int
+}
+
+func (err *RejectError) Error() string {
+	return "Server rejected connection: " + err.Reason
+}
+
+// Temporary reports whether trying again later may work, such as when the
+// server is full.
+func (err *RejectError) Temporary() bool {
+	return err.Code == transport.CloseTryAgainLater
+}
+
 type clientShared struct {
+	// Round-trip time the server measured with its pings, in nanoseconds.
+	// It is first so it is 64-bit aligned for atomic access.
+	serverRTT int64
+
+	// Round-trip time to the server, measured by our pings.
+	latency latency.Tracker
+
+	// The server's clock and tick, estimated from its pongs.
+	clock latency.Clock
+
+	// The connection to the server, usually a websocket.
+	conn transport.Conn
+
+	// Path of the websocket endpoint on the server.
+	path string
+
+	// Display name sent to the server in Hello.
+	name string
+
+	// Connects to the server, set by Dial, DialTLS and DialWith. It is
+	// called again to reconnect.
+	dialConn func() (transport.Conn, error)
+
+	// Whether to reconnect after losing connection, see SetReconnect.
+	reconnect bool
+
+	// Simulated network conditions, see SetNetSim.
+	netSim netsim.Config
+
+	// Token from the last ConnectResponse, sent in Hello when
+	// reconnecting to resume the same session.
+	resumeToken string
+
+	// Maximum message size allowed from the server. Snapshots grow with
+	// the number of players, so it is set from the MaxClients in
+	// ConnectResponse.
+	readLimit int64
+
 	// Inbound messages from the server.
 	recv chan []byte
 
 	// Disconnect
 	disconnect chan bool
+
+	// Receives why the client stopped trying to reconnect.
+	reconnectFailed chan error
+
+	// Closed by Close.
+	closed    chan struct{}
+	closeOnce sync.Once
 }
 
 func newClientShared() clientShared {
 	return clientShared{
-		recv:       make(chan []byte, 256),
-		disconnect: make(chan bool),
+		path:            defaultPath,
+		readLimit:       netmsg.MaxMessageSize(0),
+		recv:            make(chan []byte, 256),
+		disconnect:      make(chan bool, 1),
+		reconnectFailed: make(chan error, 1),
+		closed:          make(chan struct{}),
+	}
+}
+
+// SetPath changes the websocket path used by Dial and DialTLS.
+// This must match the path the server was configured with.
+func (c *clientShared) SetPath(path string) { c.path = path }
+
+// SetName changes the display name asked for when connecting. The server
+// may change it, such as when another player already has it.
+func (c *clientShared) SetName(name string) {
+	if len(name) > maxNameSize {
+		name = name[:maxNameSize]
+		for !utf8.ValidString(name) {
+			// Cut in the middle of a character
+			name = name[:len(name)-1]
+		}
 	}
+	c.name = name
 }
 
 func (c *clientShared) ChRecv() chan []byte { return c.recv }
 
+// ChDisconnected receives each time the connection is lost. If reconnecting
+// is enabled, the client is already trying to reconnect and the server
+// sends a new ConnectResponse once it has.
 func (c *clientShared) ChDisconnected() chan bool { return c.disconnect }
+
+// ChReconnectFailed receives the error that made the client stop trying to
+// reconnect, such as a *RejectError.
+func (c *clientShared) ChReconnectFailed() chan error { return c.reconnectFailed }
+
+// SetReconnect changes whether the client reconnects after losing
+// connection. Reconnecting within the server's resume window takes back
+// the same player.
+func (c *clientShared) SetReconnect(reconnect bool) { c.reconnect = reconnect }
+
+// SetNetSim simulates a bad network between the client and the server,
+// from the next connection on. It is for testing and off by default.
+func (c *clientShared) SetNetSim(config netsim.Config) { c.netSim = config }
+
+// isClosed reports whether Close has been called.
+func (c *clientShared) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// disconnected tells the application the connection was lost, without
+// blocking if it hasn't noticed the last time yet.
+func (c *clientShared) disconnected() {
+	select {
+	case c.disconnect <- true:
+	default:
+	}
+}
+
+func (c *clientShared) Dial(addr string) error {
+	url := "ws://" + addr + c.path
+	return c.DialWith(func() (transport.Conn, error) { return transport.DialWebsocket(url) })
+}
+
+func (c *clientShared) DialTLS(addr string) error {
+	url := "wss://" + addr + c.path
+	return c.DialWith(func() (transport.Conn, error) { return transport.DialWebsocket(url) })
+}
+
+// DialWith connects with the given function, such as the Dial method of a
+// transport.PipeListener to connect to a server in the same process. It
+// is called again to reconnect.
+func (c *clientShared) DialWith(dial func() (transport.Conn, error)) error {
+	c.dialConn = dial
+	return c.dial()
+}
+
+// dial connects and sends Hello, returning a *RejectError if the server
+// doesn't accept us.
+func (c *clientShared) dial() error {
+	conn, err := c.dialConn()
+	if err != nil {
+		return err
+	}
+	hello, err := c.hello()
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	conn.SetWriteDeadline(time.Now().Add(writeWait))
+	if err := conn.WriteMessage(hello); err != nil {
+		conn.Close()
+		return err
+	}
+	c.clock.Reset()
+	conn.SetReadDeadline(time.Now().Add(handshakeWait))
+	var buf []byte
+	for {
+		buf, err = conn.ReadMessage() // Blocks until the server replies.
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		reply, ok := c.handleLatency(buf)
+		if !ok {
+			break
+		}
+		if reply != nil {
+			conn.WriteMessage(reply)
+		}
+	}
+	conn.SetReadDeadline(time.Time{})
+	if err := c.checkHandshake(buf); err != nil {
+		if reject, ok := err.(*RejectError); ok {
+			reject.Code = closeCode(conn)
+		}
+		conn.Close()
+		return err
+	}
+	c.conn = conn
+	return nil
+}
+
+// closeCode waits for the server to close the connection and returns the
+// close code it sent, or 0 if there wasn't one.
+func closeCode(conn transport.Conn) int {
+	conn.SetReadDeadline(time.Now().Add(handshakeWait))
+	for {
+		_, err := conn.ReadMessage()
+		if closeErr, ok := err.(*transport.CloseError); ok {
+			return closeErr.Code
+		}
+		if err != nil {
+			return 0
+		}
+	}
+}
+
+// redial calls dial until it succeeds, waiting longer after each failed
+// attempt. It gives up if the server rejects us for good, such as for being
+// too old, or the client is closed.
+func (c *clientShared) redial() error {
+	for attempt := 0; ; attempt++ {
+		select {
+		case <-time.After(reconnectDelay(attempt)):
+		case <-c.closed:
+			return ErrClosed
+		}
+		err := c.dial()
+		if err == nil {
+			return nil
+		}
+		if reject, ok := err.(*RejectError); ok && !reject.Temporary() {
+			return err
+		}
+		log.Printf("Reconnect attempt %d failed: %v", attempt+1, err)
+	}
+}
+
+// reconnectDelay is how long to wait before the given reconnect attempt.
+// It is randomized a little so clients dropped at the same time don't all
+// reconnect at the same time.
+func reconnectDelay(attempt int) time.Duration {
+	delay := maxReconnectDelay
+	if attempt < 16 {
+		delay = minReconnectDelay << uint(attempt)
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)))
+}
+
+// hello is the first message sent to the server after connecting.
+func (c *clientShared) hello() ([]byte, error) {
+	return netmsg.Encode(&netmsg.Hello{
+		ProtocolVersion: netmsg.ProtocolVersion,
+		ClientBuild:     runtime.GOOS + "/" + runtime.GOARCH + " " + runtime.Version(),
+		Name:            c.name,
+		ResumeToken:     c.resumeToken,
+	})
+}
+
+// checkHandshake looks at the server's reply to Hello. A Reject is turned
+// into a *RejectError, anything else is queued to be read from ChRecv.
+// The resume token is kept from ConnectResponse for reconnecting, and the
+// read limit is set for the server's number of players.
+func (c *clientShared) checkHandshake(buf []byte) error {
+	if len(buf) > 0 {
+		switch netmsg.Kind(buf[0]) {
+		case netmsg.MsgReject:
+			_, msg, err := netmsg.Decode(buf)
+			if err != nil {
+				return err
+			}
+			reject := msg.(*netmsg.Reject)
+			return &RejectError{
+				Reason:          reject.Reason,
+				ProtocolVersion: reject.ProtocolVersion,
+			}
+		case netmsg.MsgConnectResponse:
+			if _, msg, err := netmsg.Decode(buf); err == nil {
+				res := msg.(*netmsg.ConnectResponse)
+				c.resumeToken = res.ResumeToken
+				c.readLimit = netmsg.MaxMessageSize(res.MaxClients)
+			}
+		}
+	}
+	c.recv <- buf
+	return nil
+}
+
+// RTT returns the smoothed round-trip time to the server.
+func (c *clientShared) RTT() time.Duration { return c.latency.RTT() }
+
+// Jitter returns how much the round-trip time to the server varies.
+func (c *clientShared) Jitter() time.Duration { return c.latency.Jitter() }
+
+// ServerRTT returns the round-trip time the server last told us it
+// measured.
+func (c *clientShared) ServerRTT() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.serverRTT))
+}
+
+// ServerTime returns the estimated time on the server's clock, once the
+// server has answered a ping.
+func (c *clientShared) ServerTime() (time.Duration, bool) { return c.clock.Now() }
+
+// ServerTick returns the estimated simulation tick of the server, which
+// ticks every tickDuration, once the server has answered a ping.
+func (c *clientShared) ServerTick(tickDuration time.Duration) (uint64, bool) {
+	return c.clock.Tick(tickDuration)
+}
+
+// ping returns a Ping to send to the server.
+func (c *clientShared) ping() ([]byte, error) {
+	return netmsg.Encode(&netmsg.Ping{
+		Time: int64(latency.Now()),
+		RTT:  int64(c.latency.RTT()),
+	})
+}
+
+// handleLatency handles Ping and Pong messages, which are answered and
+// measured here rather than passed to ChRecv. It reports whether buf was
+// one of them, and returns the Pong to send back for a Ping.
+func (c *clientShared) handleLatency(buf []byte) ([]byte, bool) {
+	if len(buf) == 0 {
+		return nil, false
+	}
+	switch netmsg.Kind(buf[0]) {
+	case netmsg.MsgPing:
+		_, msg, err := netmsg.Decode(buf)
+		if err != nil {
+			return nil, true
+		}
+		ping := msg.(*netmsg.Ping)
+		atomic.StoreInt64(&c.serverRTT, ping.RTT)
+		reply, err := netmsg.Encode(&netmsg.Pong{
+			PingTime: ping.Time,
+			Time:     int64(latency.Now()),
+		})
+		if err != nil {
+			return nil, true
+		}
+		return reply, true
+	case netmsg.MsgPong:
+		if _, msg, err := netmsg.Decode(buf); err == nil {
+			pong := msg.(*netmsg.Pong)
+			now := latency.Now()
+			c.latency.Add(now - time.Duration(pong.PingTime))
+			c.clock.Add(time.Duration(pong.Time), pong.Tick, time.Duration(pong.PingTime), now)
+		}
+		return nil, true
+	}
+	return nil, false
+}
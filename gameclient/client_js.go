@@ -3,52 +3,67 @@
 package gameclient
 
 import (
-	"net"
+	"time"
 
-	"github.com/gopherjs/websocket"
+	"github.com/silbinarywolf/networkplatformer-go/netsim"
 )
 
 type Client struct {
 	clientShared
 
-	conn net.Conn
-
 	// Outbound messages to the server.
 	send chan []byte
+
+	// Simulated network messages are sent through, if enabled with
+	// SetNetSim.
+	out *netsim.Link
 }
 
 func NewClient() *Client {
 	return &Client{
 		clientShared: newClientShared(),
-		conn:         nil,
-	}
-}
-
-func (c *Client) Dial(addr string) error {
-	conn, err := websocket.Dial("ws://" + addr + "/ws") // Blocks until connection is established.
-	if err != nil {
-		// handle error
-		return err
 	}
-	c.conn = conn
-	return nil
 }
 
-func (c *Client) DialTLS(addr string) error {
-	conn, err := websocket.Dial("wss://" + addr + "/ws") // Blocks until connection is established.
-	if err != nil {
-		// handle error
-		return err
+// Listen reads messages until the connection is lost, then reconnects if
+// enabled with SetReconnect.
+func (c *Client) Listen() error {
+	for {
+		stop := make(chan struct{})
+		if c.netSim.Enabled() {
+			conn := c.conn
+			c.out = netsim.NewLink(c.netSim, func(message []byte) {
+				conn.WriteMessage(message)
+			})
+		}
+		go c.pingPump(stop)
+		err := c.readPump() // this is blocking
+		close(stop)
+		if c.out != nil {
+			c.out.Close()
+			c.out = nil
+		}
+		c.conn.Close()
+		c.disconnected()
+		if !c.reconnect || c.isClosed() {
+			return err
+		}
+		if err := c.redial(); err != nil {
+			c.reconnectFailed <- err
+			return err
+		}
 	}
-	c.conn = conn
-	return nil
 }
 
-func (c *Client) Listen() error {
-	err := c.readPump() // this is blocking
-	c.disconnect <- true
-	c.conn.Close()
-	return err
+// Close disconnects from the server and stops the client reconnecting.
+func (c *Client) Close() {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		// Nothing to close if the first dial hasn't succeeded yet
+		if c.conn != nil {
+			c.conn.Close()
+		}
+	})
 }
 
 func (c *Client) SendMessage(message []byte) {
@@ -60,11 +75,30 @@ func (c *Client) SendMessage(message []byte) {
 	// (this function returns nil)
 	//
 	//c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-	c.conn.Write(message)
+	c.write(message)
+}
+
+// write writes a message to the connection, through the simulated network
+// if there is one.
+func (c *Client) write(message []byte) {
+	if c.out != nil {
+		c.out.Send(message)
+		return
+	}
+	c.conn.WriteMessage(message)
 }
 
 func (c *Client) readPump() error {
 	conn := c.conn
+	conn.SetReadLimit(c.readLimit)
+
+	// With a simulated network, messages go through the link first.
+	receive := c.receive
+	if c.netSim.Enabled() {
+		link := netsim.NewLink(c.netSim, c.receive)
+		defer link.Close()
+		receive = link.Send
+	}
 	for {
 		// NOTE(Jake): 2018-06-20
 		//
@@ -74,18 +108,41 @@ func (c *Client) readPump() error {
 		//
 		//conn.SetReadDeadline(time.Now().Add(pongWait))
 
-		// todo(Jake): 2018-05-27
-		//
-		// Perhaps profile / figure out how keep allocations here low?
-		// Maybe this isnt even a big deal?
-		//
-		buf := make([]byte, 1024)
-		size, err := conn.Read(buf) // Blocks until a WebSocket frame is received.
+		buf, err := conn.ReadMessage() // Blocks until a WebSocket frame is received.
 		if err != nil {
 			// handle error
 			return err
 		}
-		buf = buf[:size]
-		c.recv <- buf
+		receive(buf)
+	}
+}
+
+// receive hands a message from the server to the application.
+func (c *Client) receive(buf []byte) {
+	if reply, ok := c.handleLatency(buf); ok {
+		if reply != nil {
+			c.write(reply)
+		}
+		return
+	}
+	c.recv <- buf
+}
+
+// pingPump pings the server to measure round-trip time until stop is
+// closed.
+func (c *Client) pingPump(stop chan struct{}) {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ping, err := c.ping()
+			if err != nil {
+				return
+			}
+			c.write(ping)
+		case <-stop:
+			return
+		}
 	}
 }
@@ -9,115 +9,218 @@ import (
 	"log"
 	"time"
 
-	"github.com/gorilla/websocket"
+	"github.com/silbinarywolf/networkplatformer-go/netsim"
+	"github.com/silbinarywolf/networkplatformer-go/transport"
 )
 
 type Client struct {
 	clientShared
-	conn *websocket.Conn
 
 	// Outbound messages to the server.
 	send chan []byte
+
+	// Pongs for writePump to send.
+	pongs chan []byte
 }
 
 func NewClient() *Client {
 	return &Client{
 		clientShared: newClientShared(),
-		conn:         nil,
 		send:         make(chan []byte, 256),
+		pongs:        make(chan []byte, 1),
 	}
 }
 
-func (c *Client) Dial(addr string) error {
-	conn, _, err := websocket.DefaultDialer.Dial("ws://"+addr+"/ws", nil)
-	if err != nil {
-		return err
-	}
-	c.conn = conn
-	return nil
+func (c *Client) Listen() {
+	go c.run()
 }
 
-func (c *Client) DialTLS(addr string) error {
-	conn, _, err := websocket.DefaultDialer.Dial("wss://"+addr+"/ws", nil)
-	if err != nil {
-		return err
-	}
-	c.conn = conn
-	return nil
-}
+// run pumps messages until the connection is lost, then reconnects if
+// enabled with SetReconnect.
+func (c *Client) run() {
+	for {
+		if c.isClosed() {
+			// Closed before we got going
+			c.conn.Close()
+			c.disconnected()
+			return
+		}
+		stop := make(chan struct{})
+		writeDone := make(chan struct{})
+		go func() {
+			c.writePump(stop)
+			close(writeDone)
+		}()
+		c.readPump()
+		close(stop)
+		<-writeDone
+		c.conn.Close()
+		c.disconnected()
+		if !c.reconnect || c.isClosed() {
+			return
+		}
+		if err := c.redial(); err != nil {
+			c.reconnectFailed <- err
+			return
+		}
 
-func (c *Client) Listen() {
-	go c.writePump()
-	go c.readPump()
+		// Anything queued was meant for the old connection
+	Drain:
+		for {
+			select {
+			case <-c.send:
+			default:
+				break Drain
+			}
+		}
+	}
 }
 
 func (c *Client) ChRecv() chan []byte { return c.recv }
 
+// Close disconnects from the server and stops the client reconnecting.
+// It doesn't wait for the connection to finish closing.
+func (c *Client) Close() {
+	c.closeOnce.Do(func() { close(c.closed) })
+}
+
+// SendMessage queues a message to be sent to the server. Unlike the
+// server's sends it blocks while the buffer is full, which only happens if
+// the connection stalls with 256 messages waiting.
 func (c *Client) SendMessage(message []byte) {
 	c.send <- message
 }
 
-// readPump pumps messages from the websocket connection to the hub.
+// readPump pumps messages from the connection to the hub.
 //
 // The application runs readPump in a per-connection goroutine. The application
 // ensures that there is at most one reader on a connection by executing all
 // reads from this goroutine.
 func (c *Client) readPump() {
-	defer func() {
-		c.conn.Close()
-		c.disconnect <- true
-	}()
-	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadLimit(c.readLimit)
 	c.conn.SetReadDeadline(time.Now().Add(pongWait))
-	c.conn.SetPongHandler(func(string) error { c.conn.SetReadDeadline(time.Now().Add(pongWait)); return nil })
+	c.conn.SetPongHandler(func() { c.conn.SetReadDeadline(time.Now().Add(pongWait)) })
+
+	// With a simulated network, messages go through the link first.
+	receive := c.receive
+	var link *netsim.Link
+	if c.netSim.Enabled() {
+		link = netsim.NewLink(c.netSim, c.receive)
+		receive = link.Send
+	}
 	for {
-		_, buf, err := c.conn.ReadMessage()
+		buf, err := c.conn.ReadMessage()
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+			if transport.IsUnexpectedCloseError(err, transport.CloseNormalClosure, transport.CloseGoingAway, transport.CloseAbnormalClosure) {
 				log.Printf("error: %v", err)
 			}
 			break
 		}
-		c.recv <- buf
+		receive(buf)
+	}
+	if link != nil {
+		link.Close()
+	}
+}
+
+// receive hands a message from the server to the application.
+func (c *Client) receive(buf []byte) {
+	if reply, ok := c.handleLatency(buf); ok {
+		if reply != nil {
+			select {
+			case c.pongs <- reply:
+			default:
+				// Still sending the last one
+			}
+		}
+		return
 	}
+	c.recv <- buf
 }
 
-// writePump pumps messages from the hub to the websocket connection.
+// writePump pumps messages from the hub to the connection.
 //
 // A goroutine running writePump is started for each connection. The
 // application ensures that there is at most one writer to a connection by
 // executing all writes from this goroutine.
-func (c *Client) writePump() {
+func (c *Client) writePump(stop chan struct{}) {
 	ticker := time.NewTicker(pingPeriod)
+	latencyTicker := time.NewTicker(pingInterval)
 	defer func() {
 		ticker.Stop()
+		latencyTicker.Stop()
 		c.conn.Close()
-		c.disconnect <- true
 	}()
+
+	// With a simulated network, messages go through the link first and
+	// are written when they come out of it.
+	write := c.write
+	var delayed chan []byte
+	if c.netSim.Enabled() {
+		delayed = make(chan []byte, cap(c.send))
+		linkStop := make(chan struct{})
+		link := netsim.NewLink(c.netSim, func(message []byte) {
+			select {
+			case delayed <- message:
+			case <-linkStop:
+			}
+		})
+		defer func() {
+			close(linkStop)
+			link.Close()
+		}()
+		write = func(message []byte) error {
+			link.Send(message)
+			return nil
+		}
+	}
+
 	for {
 		select {
-		case message, ok := <-c.send:
+		case <-stop:
+			return
+		case <-c.closed:
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			c.conn.WriteClose(transport.CloseNormalClosure, "")
+			return
+		case message, ok := <-c.send:
 			if !ok {
 				// The hub closed the channel.
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+				c.conn.WriteClose(transport.CloseNormalClosure, "")
 				return
 			}
-
-			w, err := c.conn.NextWriter(websocket.BinaryMessage)
-			if err != nil {
+			if err := write(message); err != nil {
 				return
 			}
-			w.Write(message)
-
-			if err := w.Close(); err != nil {
+		case message := <-delayed:
+			if err := c.write(message); err != nil {
 				return
 			}
 		case <-ticker.C:
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+			if err := c.conn.Ping(); err != nil {
+				return
+			}
+		case <-latencyTicker.C:
+			ping, err := c.ping()
+			if err != nil {
+				return
+			}
+			if err := write(ping); err != nil {
+				return
+			}
+		case pong := <-c.pongs:
+			if err := write(pong); err != nil {
 				return
 			}
 		}
 	}
 }
+
+// write writes a message to the connection, it must only be called by
+// writePump.
+func (c *Client) write(message []byte) error {
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.conn.WriteMessage(message)
+}
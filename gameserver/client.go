@@ -5,36 +5,16 @@
 package gameserver
 
 import (
-	"net/http"
+	"log"
+	"sync/atomic"
 	"time"
 
-	"github.com/gorilla/websocket"
+	"github.com/silbinarywolf/networkplatformer-go/latency"
+	"github.com/silbinarywolf/networkplatformer-go/netmsg"
+	"github.com/silbinarywolf/networkplatformer-go/netsim"
+	"github.com/silbinarywolf/networkplatformer-go/transport"
 )
 
-const (
-	// Time allowed to write a message to the peer.
-	writeWait = 1000 * time.Millisecond
-
-	// Time allowed to read the next pong message from the peer.
-	pongWait = 60 * time.Second
-
-	// Send pings to peer with this period. Must be less than pongWait.
-	pingPeriod = (pongWait * 9) / 10
-
-	// Maximum message size allowed from peer.
-	maxMessageSize = 128
-)
-
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		// todo(Jake): Make sure this URL is validated.
-		println("Client from URL: ", r.URL.String())
-		return true
-	},
-}
-
 type Message struct {
 	client *Client
 	data   []byte
@@ -43,21 +23,44 @@ type Message struct {
 func (message *Message) Client() *Client { return message.client }
 func (message *Message) Data() []byte    { return message.data }
 
-// Client is a middleman between the websocket connection and the hub.
+// Client is a middleman between the connection and the hub.
 type Client struct {
+	// Round-trip time the client measured with its pings, in nanoseconds.
+	// It is first so it is 64-bit aligned for atomic access.
+	clientRTT int64
+
+	// Messages dropped because the client wasn't keeping up, see
+	// Options.SendPolicy.
+	dropped uint64
+
 	server *Server
 
-	// The websocket connection.
-	conn *websocket.Conn
+	// The connection, usually a websocket.
+	conn transport.Conn
 
-	// Buffered channel of outbound messages.
-	send chan []byte
+	// Outbound messages, waiting for writePump.
+	send *sendQueue
+
+	// Pings from the client for writePump to answer.
+	pings chan *netmsg.Ping
+
+	// Round-trip time to the client, measured by our pings.
+	latency latency.Tracker
 
 	// Client slot
 	clientSlot int32
 
 	// Arbitrary data for user-code use. Store the related player entity, etc.
 	data interface{}
+
+	// What the client sent when it connected
+	hello *netmsg.Hello
+
+	// Secret the client can reconnect with to resume this session
+	resumeToken string
+
+	// Whether this client took over a suspended session
+	resumed bool
 }
 
 func (c *Client) SetData(data interface{}) {
@@ -72,73 +75,193 @@ func (c *Client) ClientSlot() int32 {
 	return c.clientSlot
 }
 
-func (c *Client) SendMessage(message []byte) {
-	c.send <- message
+// Hello returns the Hello message the client connected with.
+func (c *Client) Hello() *netmsg.Hello {
+	return c.hello
+}
+
+// ResumeToken returns the token the client can reconnect with to resume
+// their session, it should be sent to them when they connect.
+func (c *Client) ResumeToken() string {
+	return c.resumeToken
+}
+
+// Resumed reports whether the client reconnected and took over a session
+// suspended by SuspendClient. Resumed clients keep their slot and the data
+// set with RegisterClient.
+func (c *Client) Resumed() bool {
+	return c.resumed
+}
+
+// SendMessage queues a message to be sent to the client. It never blocks,
+// if the client isn't keeping up Options.SendPolicy decides what happens.
+// It returns false once the client has been removed or is being
+// disconnected, the message isn't sent then.
+func (c *Client) SendMessage(message []byte) bool {
+	dropped, ok := c.send.push(message, c.server.options.SendPolicy, c.server.options.SendBufferSize)
+	if dropped > 0 {
+		atomic.AddUint64(&c.dropped, uint64(dropped))
+	}
+	if !ok && c.send.close() {
+		// readPump sees the connection close and unregisters them
+		log.Printf("client #%d isn't keeping up, disconnecting (%s)", c.clientSlot, c.server.options.SendPolicy)
+		c.conn.Close()
+	}
+	return ok
+}
+
+// Dropped returns how many messages to the client have been dropped
+// because it wasn't keeping up.
+func (c *Client) Dropped() uint64 {
+	return atomic.LoadUint64(&c.dropped)
 }
 
-// readPump pumps messages from the websocket connection to the hub.
+// readPump pumps messages from the connection to the hub.
 //
 // The application runs readPump in a per-connection goroutine. The application
 // ensures that there is at most one reader on a connection by executing all
 // reads from this goroutine.
 func (c *Client) readPump() {
-	c.conn.SetReadLimit(maxMessageSize)
+	defer c.server.wg.Done()
+	pongWait := c.server.options.PongWait
+	c.conn.SetReadLimit(c.server.options.MaxMessageSize)
 	c.conn.SetReadDeadline(time.Now().Add(pongWait))
-	c.conn.SetPongHandler(func(string) error { c.conn.SetReadDeadline(time.Now().Add(pongWait)); return nil })
+	c.conn.SetPongHandler(func() { c.conn.SetReadDeadline(time.Now().Add(pongWait)) })
+
+	// With a simulated network, messages go through the link first.
+	receive := c.receive
+	var link *netsim.Link
+	if c.server.options.NetSim.Enabled() {
+		link = netsim.NewLink(c.server.options.NetSim, c.receive)
+		receive = link.Send
+	}
 	for {
-		_, buf, err := c.conn.ReadMessage()
+		buf, err := c.conn.ReadMessage()
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				println("websocket.IsUnexpectedCloseError: " + err.Error())
+			if transport.IsUnexpectedCloseError(err, transport.CloseNormalClosure, transport.CloseGoingAway, transport.CloseAbnormalClosure) {
+				log.Printf("client #%d closed unexpectedly: %v", c.clientSlot, err)
 			}
 			break
 		}
 		//message = bytes.TrimSpace(bytes.Replace(message, newline, space, -1))
-		c.server.broadcast <- Message{
-			client: c,
-			data:   buf,
-		}
+		receive(buf)
+	}
+	if link != nil {
+		link.Close()
+	}
+	select {
+	case c.server.unregister <- c:
+	case <-c.server.done:
 	}
-	c.server.unregister <- c
 	c.conn.Close()
 }
 
-// writePump pumps messages from the hub to the websocket connection.
+// receive hands a message from the client to the game loop.
+func (c *Client) receive(buf []byte) {
+	if c.handleLatency(buf) {
+		return
+	}
+	select {
+	case c.server.broadcast <- Message{
+		client: c,
+		data:   buf,
+	}:
+	case <-c.server.done:
+		// Server is shutting down, nobody is reading messages anymore.
+	}
+}
+
+// writePump pumps messages from the hub to the connection.
 //
 // A goroutine running writePump is started for each connection. The
 // application ensures that there is at most one writer to a connection by
 // executing all writes from this goroutine.
 func (c *Client) writePump() {
+	writeWait := c.server.options.WriteWait
+	// Send pings to peer with this period. Must be less than pongWait.
+	pingPeriod := (c.server.options.PongWait * 9) / 10
 	ticker := time.NewTicker(pingPeriod)
+	latencyTicker := time.NewTicker(c.server.options.PingInterval)
 	defer func() {
 		ticker.Stop()
-		c.conn.Close()
+		latencyTicker.Stop()
+		c.server.wg.Done()
 	}()
+
+	// With a simulated network, messages go through the link first and
+	// are written when they come out of it.
+	write := c.write
+	var delayed chan []byte
+	if c.server.options.NetSim.Enabled() {
+		delayed = make(chan []byte, c.server.options.SendBufferSize)
+		stop := make(chan struct{})
+		link := netsim.NewLink(c.server.options.NetSim, func(message []byte) {
+			select {
+			case delayed <- message:
+			case <-stop:
+			}
+		})
+		defer func() {
+			close(stop)
+			link.Close()
+		}()
+		write = func(message []byte) error {
+			link.Send(message)
+			return nil
+		}
+	}
+
+	var messages [][]byte
 	for {
 		select {
-		case message, ok := <-c.send:
-			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if !ok {
-				// The server closed the channel.
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
-				return
+		case <-c.send.ready:
+			var closed bool
+			messages, closed = c.send.pop(messages)
+			for _, message := range messages {
+				if err := write(message); err != nil {
+					log.Printf("client #%d disconnected: %v", c.clientSlot, err)
+					c.conn.Close()
+					return
+				}
 			}
-
-			w, err := c.conn.NextWriter(websocket.BinaryMessage)
-			if err != nil {
+			if closed {
+				// The server closed the queue. Start the close handshake
+				// and give readPump a moment to receive the peer's reply, it
+				// closes the connection once it does.
+				c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+				c.conn.WriteClose(transport.CloseNormalClosure, "")
+				c.conn.SetReadDeadline(time.Now().Add(writeWait))
 				return
 			}
-			w.Write(message)
-
-			if err := w.Close(); err != nil {
-				println("Client disconnected. Err = ", err)
+		case message := <-delayed:
+			if err := c.write(message); err != nil {
+				log.Printf("client #%d disconnected: %v", c.clientSlot, err)
+				c.conn.Close()
 				return
 			}
 		case <-ticker.C:
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+			if err := c.conn.Ping(); err != nil {
+				c.conn.Close()
+				return
+			}
+		case <-latencyTicker.C:
+			if err := c.writeLatency(write, c.ping()); err != nil {
+				c.conn.Close()
+				return
+			}
+		case ping := <-c.pings:
+			if err := c.writeLatency(write, c.pong(ping)); err != nil {
+				c.conn.Close()
 				return
 			}
 		}
 	}
 }
+
+// write writes a message to the connection, it must only be called by
+// writePump.
+func (c *Client) write(message []byte) error {
+	c.conn.SetWriteDeadline(time.Now().Add(c.server.options.WriteWait))
+	return c.conn.WriteMessage(message)
+}
@@ -0,0 +1,62 @@
+package gameserver
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/silbinarywolf/networkplatformer-go/netmsg"
+	"github.com/silbinarywolf/networkplatformer-go/transport"
+)
+
+// maxHelloSize is the largest Hello accepted. Hello carries the client's
+// build and name, which can be longer than Options.MaxMessageSize allows
+// for the messages sent while playing.
+const maxHelloSize = 1024
+
+var (
+	ErrExpectedHello = errors.New("Client didn't start with Hello.")
+)
+
+// handshake waits for the client's Hello and checks it speaks our protocol
+// version. Clients that don't are sent a Reject explaining why before an
+// error is returned, the caller closes the connection.
+func (s *Server) handshake(conn transport.Conn) (*netmsg.Hello, error) {
+	conn.SetReadLimit(maxHelloSize)
+	conn.SetReadDeadline(time.Now().Add(s.options.HandshakeWait))
+	buf, err := conn.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+	_, msg, err := netmsg.Decode(buf)
+	hello, ok := msg.(*netmsg.Hello)
+	if err != nil || !ok {
+		s.reject(conn, transport.ClosePolicyViolation, "Expected Hello, you may need to update your client.")
+		return nil, ErrExpectedHello
+	}
+	if hello.ProtocolVersion != netmsg.ProtocolVersion {
+		reason := fmt.Sprintf("Protocol version %d is not supported, the server uses version %d.", hello.ProtocolVersion, netmsg.ProtocolVersion)
+		s.reject(conn, transport.ClosePolicyViolation, reason)
+		return nil, fmt.Errorf("client %q has protocol version %d, want %d", hello.ClientBuild, hello.ProtocolVersion, netmsg.ProtocolVersion)
+	}
+	conn.SetReadDeadline(time.Time{})
+	return hello, nil
+}
+
+// reject sends the client a Reject with the given reason and starts the
+// close handshake with the close code. It is used before the client has
+// pumps running, so writes to the connection directly.
+func (s *Server) reject(conn transport.Conn, code int, reason string) {
+	packetData, err := netmsg.Encode(&netmsg.Reject{
+		Reason:          reason,
+		ProtocolVersion: netmsg.ProtocolVersion,
+	})
+	if err != nil {
+		return
+	}
+	conn.SetWriteDeadline(time.Now().Add(s.options.WriteWait))
+	if err := conn.WriteMessage(packetData); err != nil {
+		return
+	}
+	conn.WriteClose(code, "")
+}
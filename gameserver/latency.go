@@ -0,0 +1,84 @@
+package gameserver
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/silbinarywolf/networkplatformer-go/latency"
+	"github.com/silbinarywolf/networkplatformer-go/netmsg"
+)
+
+// SetTick sets the simulation tick sent to clients, so they can estimate
+// the server's tick. It is safe to call from any goroutine.
+func (s *Server) SetTick(tick uint64) {
+	atomic.StoreUint64(&s.tick, tick)
+}
+
+// RTT returns the smoothed round-trip time to the client.
+func (c *Client) RTT() time.Duration {
+	return c.latency.RTT()
+}
+
+// Jitter returns how much the round-trip time to the client varies.
+func (c *Client) Jitter() time.Duration {
+	return c.latency.Jitter()
+}
+
+// ClientRTT returns the round-trip time the client last told us it
+// measured.
+func (c *Client) ClientRTT() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.clientRTT))
+}
+
+// handleLatency handles Ping and Pong messages, which are answered and
+// measured by the pumps rather than passed to the game loop. It reports
+// whether buf was one of them.
+func (c *Client) handleLatency(buf []byte) bool {
+	if len(buf) == 0 {
+		return false
+	}
+	switch netmsg.Kind(buf[0]) {
+	case netmsg.MsgPing:
+		if _, msg, err := netmsg.Decode(buf); err == nil {
+			ping := msg.(*netmsg.Ping)
+			atomic.StoreInt64(&c.clientRTT, ping.RTT)
+			select {
+			case c.pings <- ping:
+			default:
+				// Still answering the last one
+			}
+		}
+		return true
+	case netmsg.MsgPong:
+		if _, msg, err := netmsg.Decode(buf); err == nil {
+			c.latency.Add(latency.Now() - time.Duration(msg.(*netmsg.Pong).PingTime))
+		}
+		return true
+	}
+	return false
+}
+
+func (c *Client) ping() *netmsg.Ping {
+	return &netmsg.Ping{
+		Time: int64(latency.Now()),
+		RTT:  int64(c.latency.RTT()),
+	}
+}
+
+func (c *Client) pong(ping *netmsg.Ping) *netmsg.Pong {
+	return &netmsg.Pong{
+		PingTime: ping.Time,
+		Time:     int64(latency.Now()),
+		Tick:     atomic.LoadUint64(&c.server.tick),
+	}
+}
+
+// writeLatency writes a Ping or Pong with write, it must only be called by
+// writePump.
+func (c *Client) writeLatency(write func([]byte) error, msg netmsg.Message) error {
+	packetData, err := netmsg.Encode(msg)
+	if err != nil {
+		return err
+	}
+	return write(packetData)
+}
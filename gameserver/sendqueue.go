@@ -0,0 +1,169 @@
+package gameserver
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/silbinarywolf/networkplatformer-go/netmsg"
+)
+
+// SendPolicy is what happens when a message is sent to a client whose send
+// buffer is full, because they aren't keeping up.
+type SendPolicy int
+
+const (
+	// The oldest queued state message is dropped to make room, see
+	// isStateMessage. If only other messages are queued, the client is
+	// disconnected.
+	SendDropOldest SendPolicy = iota
+	// At most one state message of each kind is queued, a newer one
+	// replaces it. If the buffer is still full, the client is
+	// disconnected.
+	SendCoalesce
+	// The client is disconnected
+	SendDisconnect
+)
+
+var sendPolicyToString = []string{
+	SendDropOldest: "drop-oldest",
+	SendCoalesce:   "coalesce",
+	SendDisconnect: "disconnect",
+}
+
+var ErrUnknownSendPolicy = errors.New("Unknown send policy, expected drop-oldest, coalesce or disconnect.")
+
+func (p SendPolicy) String() string {
+	if int(p) >= 0 && int(p) < len(sendPolicyToString) {
+		return sendPolicyToString[p]
+	}
+	return "unknown"
+}
+
+// Set parses the send policy from a string, so it can be used as a flag.
+func (p *SendPolicy) Set(value string) error {
+	for i, name := range sendPolicyToString {
+		if name == value {
+			*p = SendPolicy(i)
+			return nil
+		}
+	}
+	return ErrUnknownSendPolicy
+}
+
+// isStateMessage reports whether a message only carries state that a newer
+// message of the same kind replaces, so it can be dropped when the client
+// isn't keeping up.
+func isStateMessage(message []byte) bool {
+	if len(message) == 0 {
+		return false
+	}
+	switch netmsg.Kind(message[0]) {
+	case netmsg.MsgWorldSnapshot, netmsg.MsgUpdatePlayer:
+		return true
+	}
+	return false
+}
+
+// sendQueue holds a client's outbound messages until writePump writes
+// them. Pushing never blocks.
+type sendQueue struct {
+	mu       sync.Mutex
+	messages [][]byte
+	closed   bool
+
+	// Receives when messages are pushed or the queue is closed
+	ready chan struct{}
+}
+
+func newSendQueue(size int) *sendQueue {
+	return &sendQueue{
+		messages: make([][]byte, 0, size),
+		ready:    make(chan struct{}, 1),
+	}
+}
+
+// push queues a message, making room as the policy says if there are
+// already max queued. It returns how many messages were dropped, and
+// false if the queue is closed or the client should be disconnected.
+func (q *sendQueue) push(message []byte, policy SendPolicy, max int) (int, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return 0, false
+	}
+	dropped := 0
+	if policy == SendCoalesce && isStateMessage(message) {
+		if i := q.index(message[0]); i >= 0 {
+			q.remove(i)
+			dropped++
+		}
+	}
+	if len(q.messages) >= max {
+		if policy != SendDropOldest {
+			return dropped, false
+		}
+		if i := q.index(0); i >= 0 {
+			q.remove(i)
+			dropped++
+		} else if isStateMessage(message) {
+			// Nothing older to drop, so drop this one
+			return dropped + 1, true
+		} else {
+			return dropped, false
+		}
+	}
+	q.messages = append(q.messages, message)
+	q.signal()
+	return dropped, true
+}
+
+// index returns the index of the oldest queued state message of the given
+// kind, or of any kind if kind is 0. It returns -1 if there are none.
+func (q *sendQueue) index(kind byte) int {
+	for i, message := range q.messages {
+		if isStateMessage(message) && (kind == 0 || message[0] == kind) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (q *sendQueue) remove(i int) {
+	copy(q.messages[i:], q.messages[i+1:])
+	q.messages[len(q.messages)-1] = nil
+	q.messages = q.messages[:len(q.messages)-1]
+}
+
+// pop takes every queued message, and reports whether the queue has been
+// closed. Messages queued before it was closed are still returned.
+func (q *sendQueue) pop(messages [][]byte) ([][]byte, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	messages = append(messages[:0], q.messages...)
+	for i := range q.messages {
+		q.messages[i] = nil
+	}
+	q.messages = q.messages[:0]
+	return messages, q.closed
+}
+
+// close stops messages being pushed. It reports whether the queue was
+// open.
+func (q *sendQueue) close() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return false
+	}
+	q.closed = true
+	q.signal()
+	return true
+}
+
+// signal wakes writePump, it must be called with mu held.
+func (q *sendQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
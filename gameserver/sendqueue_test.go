@@ -0,0 +1,135 @@
+package gameserver
+
+import (
+	"testing"
+
+	"github.com/silbinarywolf/networkplatformer-go/netmsg"
+	"github.com/silbinarywolf/networkplatformer-go/transport"
+)
+
+// Messages are just their kind and a number to tell them apart
+var (
+	snapshot1 = []byte{byte(netmsg.MsgWorldSnapshot), 1}
+	snapshot2 = []byte{byte(netmsg.MsgWorldSnapshot), 2}
+	snapshot3 = []byte{byte(netmsg.MsgWorldSnapshot), 3}
+	chat1     = []byte{byte(netmsg.MsgChatMessage), 1}
+	chat2     = []byte{byte(netmsg.MsgChatMessage), 2}
+	chat3     = []byte{byte(netmsg.MsgChatMessage), 3}
+)
+
+// newTestClient returns a client that isn't connected to anything, with
+// a send buffer of 2 messages.
+func newTestClient(policy SendPolicy) (*Client, transport.Conn) {
+	s := NewServer(Options{
+		SendBufferSize: 2,
+		SendPolicy:     policy,
+	})
+	conn, peer := transport.Pipe()
+	client := &Client{
+		server: s,
+		conn:   conn,
+		send:   newSendQueue(s.options.SendBufferSize),
+	}
+	return client, peer
+}
+
+func queued(c *Client) [][]byte {
+	messages, _ := c.send.pop(nil)
+	return messages
+}
+
+func checkQueued(t *testing.T, c *Client, want ...[]byte) {
+	t.Helper()
+	got := queued(c)
+	if len(got) != len(want) {
+		t.Fatalf("got %v queued, want %v", got, want)
+	}
+	for i := range got {
+		if string(got[i]) != string(want[i]) {
+			t.Fatalf("got %v queued, want %v", got, want)
+		}
+	}
+}
+
+func TestSendDropOldest(t *testing.T) {
+	c, _ := newTestClient(SendDropOldest)
+	c.SendMessage(snapshot1)
+	c.SendMessage(chat1)
+	if !c.SendMessage(snapshot2) {
+		t.Fatal("client was disconnected")
+	}
+	checkQueued(t, c, chat1, snapshot2)
+	if c.Dropped() != 1 {
+		t.Fatalf("got %d dropped, want 1", c.Dropped())
+	}
+
+	// With only messages that can't be dropped queued, a new state
+	// message is dropped instead
+	c.SendMessage(chat1)
+	c.SendMessage(chat2)
+	if !c.SendMessage(snapshot3) {
+		t.Fatal("client was disconnected")
+	}
+	checkQueued(t, c, chat1, chat2)
+	if c.Dropped() != 2 {
+		t.Fatalf("got %d dropped, want 2", c.Dropped())
+	}
+
+	// And anything else disconnects them
+	c.SendMessage(chat1)
+	c.SendMessage(chat2)
+	if c.SendMessage(chat3) {
+		t.Fatal("client wasn't disconnected")
+	}
+}
+
+func TestSendCoalesce(t *testing.T) {
+	c, _ := newTestClient(SendCoalesce)
+	c.SendMessage(snapshot1)
+	c.SendMessage(chat1)
+	c.SendMessage(snapshot2)
+	if !c.SendMessage(snapshot3) {
+		t.Fatal("client was disconnected")
+	}
+	checkQueued(t, c, chat1, snapshot3)
+	if c.Dropped() != 2 {
+		t.Fatalf("got %d dropped, want 2", c.Dropped())
+	}
+
+	c.SendMessage(chat1)
+	c.SendMessage(chat2)
+	if c.SendMessage(chat3) {
+		t.Fatal("client wasn't disconnected")
+	}
+}
+
+func TestSendDisconnect(t *testing.T) {
+	c, peer := newTestClient(SendDisconnect)
+	c.SendMessage(snapshot1)
+	c.SendMessage(snapshot2)
+	if c.SendMessage(snapshot3) {
+		t.Fatal("client wasn't disconnected")
+	}
+	if _, err := peer.ReadMessage(); err == nil {
+		t.Fatal("connection wasn't closed")
+	}
+	if c.SendMessage(chat1) {
+		t.Fatal("sent to disconnected client")
+	}
+}
+
+func TestSendAfterRemove(t *testing.T) {
+	c, _ := newTestClient(SendDropOldest)
+	c.server.RegisterClient(c, nil)
+	c.SendMessage(chat1)
+	c.server.RemoveClient(c)
+
+	// Doesn't panic, and what was queued is still written
+	if c.SendMessage(chat2) {
+		t.Fatal("sent to removed client")
+	}
+	messages, closed := c.send.pop(nil)
+	if !closed || len(messages) != 1 {
+		t.Fatalf("got %v queued and closed %v, want 1 message and closed", messages, closed)
+	}
+}
@@ -1,23 +1,173 @@
 package gameserver
 
 import (
+	"context"
 	"errors"
 	"log"
 	"net/http"
-)
+	"sync"
+	"time"
 
-const (
-	maxClients = 256
+	"github.com/gorilla/websocket"
+	"github.com/silbinarywolf/networkplatformer-go/netmsg"
+	"github.com/silbinarywolf/networkplatformer-go/netsim"
+	"github.com/silbinarywolf/networkplatformer-go/transport"
 )
 
 var (
 	ErrNoMoreClientSlots = errors.New("No more free client slots.")
+	ErrServerClosed      = errors.New("Server has been shut down.")
 )
 
+// Options configures a Server.
+type Options struct {
+	// Address to listen on, ie. ":8080"
+	Addr string
+
+	// Path the websocket endpoint is served on, ie. "/ws"
+	Path string
+
+	// Maximum number of clients that can be connected at once.
+	MaxClients int
+
+	// I/O buffer sizes of the websocket connections, in bytes.
+	ReadBufferSize  int
+	WriteBufferSize int
+
+	// Number of outbound messages that can be queued for each client.
+	SendBufferSize int
+
+	// What happens when a client's send buffer is full.
+	SendPolicy SendPolicy
+
+	// Number of inbound messages per client that can wait for the game
+	// loop, so a client sending every tick doesn't wait for the game loop
+	// to take each one.
+	ReceiveBufferSize int
+
+	// Maximum message size allowed from a client after Hello, in bytes.
+	MaxMessageSize int64
+
+	// Time allowed to write a message to a client.
+	WriteWait time.Duration
+
+	// Time allowed to read the next pong message from a client.
+	// Pings are sent at 90% of this period.
+	PongWait time.Duration
+
+	// Time allowed for a client to send Hello after connecting.
+	HandshakeWait time.Duration
+
+	// How long a client that lost connection has to reconnect and
+	// resume their session before their slot is freed, see SuspendClient.
+	GracePeriod time.Duration
+
+	// How often clients are pinged to measure round-trip time.
+	PingInterval time.Duration
+
+	// Simulates a bad network on every connection, for testing. It is
+	// off unless set.
+	NetSim netsim.Config
+}
+
+// DefaultOptions returns the options used for any field left as its
+// zero value.
+func DefaultOptions() Options {
+	return Options{
+		Addr:              ":8080",
+		Path:              "/ws",
+		MaxClients:        256,
+		ReadBufferSize:    1024,
+		WriteBufferSize:   1024,
+		SendBufferSize:    256,
+		ReceiveBufferSize: 32,
+		MaxMessageSize:    128,
+		WriteWait:         1000 * time.Millisecond,
+		PongWait:          60 * time.Second,
+		HandshakeWait:     5 * time.Second,
+		GracePeriod:       30 * time.Second,
+		PingInterval:      1 * time.Second,
+	}
+}
+
+func (o *Options) setDefaults() {
+	defaults := DefaultOptions()
+	if o.Addr == "" {
+		o.Addr = defaults.Addr
+	}
+	if o.Path == "" {
+		o.Path = defaults.Path
+	}
+	if o.MaxClients <= 0 {
+		o.MaxClients = defaults.MaxClients
+	}
+	if o.ReadBufferSize <= 0 {
+		o.ReadBufferSize = defaults.ReadBufferSize
+	}
+	if o.WriteBufferSize <= 0 {
+		o.WriteBufferSize = defaults.WriteBufferSize
+	}
+	if o.SendBufferSize <= 0 {
+		o.SendBufferSize = defaults.SendBufferSize
+	}
+	if o.ReceiveBufferSize <= 0 {
+		o.ReceiveBufferSize = defaults.ReceiveBufferSize
+	}
+	if o.MaxMessageSize <= 0 {
+		o.MaxMessageSize = defaults.MaxMessageSize
+	}
+	if o.WriteWait <= 0 {
+		o.WriteWait = defaults.WriteWait
+	}
+	if o.PongWait <= 0 {
+		o.PongWait = defaults.PongWait
+	}
+	if o.HandshakeWait <= 0 {
+		o.HandshakeWait = defaults.HandshakeWait
+	}
+	if o.GracePeriod <= 0 {
+		o.GracePeriod = defaults.GracePeriod
+	}
+	if o.PingInterval <= 0 {
+		o.PingInterval = defaults.PingInterval
+	}
+}
+
 type Server struct {
-	addr string
+	// The game's simulation tick, sent to clients in Pong. It is first so
+	// it is 64-bit aligned for atomic access.
+	tick uint64
+
+	options Options
+
+	// Routes requests to the websocket endpoint. Each server has its own
+	// so that multiple servers can run in the same process.
+	mux *http.ServeMux
+
+	upgrader websocket.Upgrader
+
+	httpServer *http.Server
+
+	// Guards shuttingDown and adding to wg, so that no new connections
+	// start once Shutdown has begun waiting. Also guards clientSlots,
+	// sessions and listeners.
+	mu           sync.Mutex
+	shuttingDown bool
 
-	//
+	// Listeners being served by Serve, closed by Shutdown.
+	listeners map[transport.Listener]bool
+
+	// Suspended clients that can be resumed, by resume token.
+	sessions map[string]*session
+
+	// Closed when Shutdown is called. Client goroutines stop handing
+	// messages to the game loop once this is closed.
+	done chan struct{}
+
+	// Tracks the readPump and writePump goroutines of every connection.
+	wg sync.WaitGroup
+
+	// Which client slots are taken, by connected or suspended clients.
 	clientSlots []bool
 
 	// Registered clients.
@@ -33,39 +183,90 @@ type Server struct {
 	unregister chan *Client
 }
 
-// Create new chat server.
-func NewServer(pattern string) *Server {
-	return &Server{
-		addr:        ":8080",
-		clientSlots: make([]bool, maxClients),
-		broadcast:   make(chan Message),
+// Create new game server. Any options left as their zero value use the
+// value from DefaultOptions.
+func NewServer(options Options) *Server {
+	options.setDefaults()
+	s := &Server{
+		options: options,
+		mux:     http.NewServeMux(),
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  options.ReadBufferSize,
+			WriteBufferSize: options.WriteBufferSize,
+			CheckOrigin: func(r *http.Request) bool {
+				// todo(Jake): Make sure this URL is validated.
+				return true
+			},
+		},
+		clientSlots: make([]bool, options.MaxClients),
+		broadcast:   make(chan Message, options.MaxClients*options.ReceiveBufferSize),
 		register:    make(chan *Client),
 		unregister:  make(chan *Client),
 		clients:     make(map[*Client]bool),
+		sessions:    make(map[string]*session),
+		listeners:   make(map[transport.Listener]bool),
+		done:        make(chan struct{}),
+	}
+	s.mux.HandleFunc(options.Path, s.serveWs)
+	s.httpServer = &http.Server{
+		Addr:    options.Addr,
+		Handler: s.mux,
 	}
+	return s
 }
 
+// Options returns the options the server was created with.
+func (s *Server) Options() Options { return s.options }
+
+// Handler returns the handler that serves the websocket endpoint, for
+// running the server with your own http.Server or httptest.
+func (s *Server) Handler() http.Handler { return s.mux }
+
 // Listen and serve.
 // It serves client connection and broadcast request.
 func (s *Server) Listen() {
-	http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
-		s.serveWs(w, r)
-	})
-	println("Listening server...")
-	err := http.ListenAndServe(s.addr, nil)
-	if err != nil {
-		println("Failed to listen:", err.Error())
+	err := s.httpServer.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
+		log.Printf("Failed to listen: %v", err)
 	}
 }
 
 func (s *Server) ListenTLS(sslCert string, sslKey string) {
-	http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
-		s.serveWs(w, r)
-	})
-	println("Listening server...")
-	err := http.ListenAndServeTLS(s.addr, sslCert, sslKey, nil)
-	if err != nil {
-		println("Failed to listen:", err.Error())
+	err := s.httpServer.ListenAndServeTLS(sslCert, sslKey)
+	if err != nil && err != http.ErrServerClosed {
+		log.Printf("Failed to listen: %v", err)
+	}
+}
+
+// Serve accepts connections from the listener until it is closed or the
+// server is shut down, which closes it. It is how servers are run without
+// HTTP, such as in tests with a transport.PipeListener.
+func (s *Server) Serve(l transport.Listener) error {
+	s.mu.Lock()
+	if s.shuttingDown {
+		s.mu.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	s.listeners[l] = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.listeners, l)
+		s.mu.Unlock()
+	}()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			select {
+			case <-s.done:
+				return ErrServerClosed
+			default:
+				return err
+			}
+		}
+		go s.ServeConn(conn)
 	}
 }
 
@@ -91,49 +292,182 @@ func (s *Server) RegisterClient(c *Client, data interface{}) {
 
 func (s *Server) RemoveClient(c *Client) bool {
 	if _, ok := s.clients[c]; ok {
-		s.clientSlots[c.clientSlot] = false
-		close(c.send)
+		s.freeClientSlot(c.clientSlot)
+		c.send.close()
 		delete(s.clients, c)
 		return true
 	}
 	return false
 }
 
+// Shutdown gracefully shuts down the server. It stops accepting new
+// connections, sends every connected client a ServerShutdown message with
+// the given reason and closes each connection once its queued messages have
+// been written. It returns once every client goroutine has exited, or
+// when ctx is done, in which case any remaining connections are closed
+// forcefully and ctx.Err() is returned.
+//
+// Shutdown must be called from the goroutine that services ChRegister,
+// ChUnregister and ChBroadcast, after it has stopped doing so.
+func (s *Server) Shutdown(ctx context.Context, reason string) error {
+	s.mu.Lock()
+	if s.shuttingDown {
+		s.mu.Unlock()
+		return ErrServerClosed
+	}
+	s.shuttingDown = true
+	close(s.done)
+	for l := range s.listeners {
+		l.Close()
+	}
+	s.mu.Unlock()
+
+	// Stop accepting new connections
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		log.Printf("http shutdown: %v", err)
+	}
+
+	// Tell clients why they are being disconnected
+	packetData, err := netmsg.Encode(&netmsg.ServerShutdown{
+		Reason: reason,
+	})
+	if err != nil {
+		return err
+	}
+	clients := make([]*Client, 0, len(s.clients))
+	for client := range s.clients {
+		client.SendMessage(packetData)
+		// Closing the send queue makes writePump flush what is queued and
+		// then close the connection.
+		s.RemoveClient(client)
+		clients = append(clients, client)
+	}
+
+	// Wait for connections to finish closing
+	allDone := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(allDone)
+	}()
+	select {
+	case <-allDone:
+		return nil
+	case <-ctx.Done():
+		for _, client := range clients {
+			client.conn.Close()
+		}
+		return ctx.Err()
+	}
+}
+
 // serveWs handles websocket requests from the peer.
 func (s *Server) serveWs(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	conn, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Println(err)
 		return
 	}
-	clientSlot, err := s.getNextFreeClientSlot()
+	s.ServeConn(transport.NewWebsocketConn(conn))
+}
+
+// ServeConn does the handshake with a new connection and hands the client
+// to ChRegister. It returns once the client's pumps are running, or the
+// connection has been closed.
+func (s *Server) ServeConn(conn transport.Conn) {
+	hello, err := s.handshake(conn)
 	if err != nil {
-		log.Println(err)
+		log.Printf("handshake with %s failed: %v", conn.RemoteAddr(), err)
+		conn.Close()
 		return
 	}
 	client := &Client{
-		server:     s,
-		conn:       conn,
-		clientSlot: clientSlot,
-		send:       make(chan []byte, 256),
+		server: s,
+		conn:   conn,
+		send:   newSendQueue(s.options.SendBufferSize),
+		pings:  make(chan *netmsg.Ping, 1),
+		hello:  hello,
+	}
+	suspended, ok := s.resume(hello.ResumeToken)
+	if ok {
+		// Take over the slot and data of the session they left
+		old := suspended.client
+		client.clientSlot = old.clientSlot
+		client.data = old.data
+		client.resumeToken = old.resumeToken
+		client.resumed = true
+	} else {
+		client.resumeToken, err = newResumeToken()
+		if err != nil {
+			log.Println(err)
+			conn.Close()
+			return
+		}
+		client.clientSlot, err = s.allocateClientSlot()
+		if err != nil {
+			log.Printf("%s can't join: %v", conn.RemoteAddr(), err)
+			s.reject(conn, transport.CloseTryAgainLater, "Server is full.")
+			conn.Close()
+			return
+		}
+	}
+
+	// Account for the pump goroutines before handing the client over, so
+	// Shutdown waits for them.
+	s.mu.Lock()
+	if s.shuttingDown {
+		s.mu.Unlock()
+		s.abandonClient(client, suspended)
+		return
+	}
+	s.wg.Add(2)
+	s.mu.Unlock()
+
+	select {
+	case client.server.register <- client:
+	case <-s.done:
+		// Shutdown started before the game loop took the client.
+		s.wg.Add(-2)
+		s.abandonClient(client, suspended)
+		return
 	}
-	s.clientSlots[clientSlot] = true
-	client.server.register <- client
 
 	// Allow collection of memory referenced by the caller by doing all work in
 	// new goroutines.
 	go client.writePump()
 	go client.readPump()
+}
 
-	println("Client connected!")
+// abandonClient closes the connection of a client that was never handed
+// to the game loop. A resumed client's session is put back, as the game
+// loop still has them suspended and frees their slot once it expires.
+// Otherwise their slot is freed now.
+func (s *Server) abandonClient(c *Client, suspended *session) {
+	s.mu.Lock()
+	if suspended != nil {
+		s.sessions[c.resumeToken] = suspended
+	} else {
+		s.clientSlots[c.clientSlot] = false
+	}
+	s.mu.Unlock()
+	c.conn.Close()
 }
 
-func (s *Server) getNextFreeClientSlot() (int32, error) {
-	maxClients := s.GetMaxClients()
-	for i := int32(0); i < maxClients; i++ {
-		if !s.clientSlots[i] {
-			return i, nil
+// allocateClientSlot takes the lowest free slot. It is called by
+// connection goroutines, so slots are only changed with mu held.
+func (s *Server) allocateClientSlot() (int32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, taken := range s.clientSlots {
+		if !taken {
+			s.clientSlots[i] = true
+			return int32(i), nil
 		}
 	}
 	return 0, ErrNoMoreClientSlots
 }
+
+func (s *Server) freeClientSlot(clientSlot int32) {
+	s.mu.Lock()
+	s.clientSlots[clientSlot] = false
+	s.mu.Unlock()
+}
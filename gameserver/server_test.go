@@ -0,0 +1,247 @@
+package gameserver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/silbinarywolf/networkplatformer-go/gameclient"
+	"github.com/silbinarywolf/networkplatformer-go/netmsg"
+	"github.com/silbinarywolf/networkplatformer-go/transport"
+)
+
+// testTimeout is how long tests wait for something to arrive before
+// failing.
+const testTimeout = 5 * time.Second
+
+var errTestTimeout = errors.New("Timed out.")
+
+// startPipeServer runs a server with startTestGame on a pipe listener.
+// The returned function shuts the server down.
+func startPipeServer(t *testing.T, options Options) (*transport.PipeListener, func()) {
+	t.Helper()
+	s := NewServer(options)
+	l := transport.NewPipeListener()
+	go s.Serve(l)
+	return l, startTestGame(t, s)
+}
+
+// startTestGame runs a minimal game loop for the server, so connections
+// and slots can be tested without the game package, whose tests cover the
+// real game loop. Joining clients are sent a ConnectResponse with their
+// slot, other clients are sent a DisconnectPlayer when one leaves, and
+// every message a client sends is forwarded to the others. It fails the
+// test if two clients are given the same slot. The returned function stops
+// the loop and shuts the server down.
+func startTestGame(t *testing.T, s *Server) func() {
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	send := func(client *Client, msg netmsg.Message) {
+		packetData, err := netmsg.Encode(msg)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		client.SendMessage(packetData)
+	}
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case client := <-s.ChRegister():
+				for other := range s.GetClients() {
+					if other.ClientSlot() == client.ClientSlot() {
+						t.Errorf("slot %d given to two clients", client.ClientSlot())
+					}
+				}
+				s.RegisterClient(client, nil)
+				send(client, &netmsg.ConnectResponse{
+					ClientSlot: client.ClientSlot(),
+				})
+			case client := <-s.ChUnregister():
+				if s.RemoveClient(client) {
+					for other := range s.GetClients() {
+						send(other, &netmsg.DisconnectPlayer{
+							ClientSlot: client.ClientSlot(),
+						})
+					}
+				}
+			case message := <-s.ChBroadcast():
+				for other := range s.GetClients() {
+					if other != message.Client() {
+						other.SendMessage(message.Data())
+					}
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-stopped
+		ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+		defer cancel()
+		if err := s.Shutdown(ctx, "Test finished."); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}
+}
+
+// receive decodes the next message the client gets, skipping anything
+// that isn't of the same type as want.
+func receive(t *testing.T, client *gameclient.Client, want netmsg.Message) netmsg.Message {
+	t.Helper()
+	wantKind, _ := netmsg.KindOf(want)
+	timeout := time.After(testTimeout)
+	for {
+		select {
+		case buf := <-client.ChRecv():
+			kind, msg, err := netmsg.Decode(buf)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if kind == wantKind {
+				return msg
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s", wantKind)
+			return nil
+		}
+	}
+}
+
+func TestServePipe(t *testing.T) {
+	l, shutdown := startPipeServer(t, Options{})
+	defer shutdown()
+
+	// Clients join and are each given their own slot
+	clients := make([]*gameclient.Client, 3)
+	for i := range clients {
+		client := gameclient.NewClient()
+		if err := client.DialWith(l.Dial); err != nil {
+			t.Fatalf("client %d: dial: %v", i, err)
+		}
+		client.Listen()
+		res := receive(t, client, &netmsg.ConnectResponse{}).(*netmsg.ConnectResponse)
+		if res.ClientSlot != int32(i) {
+			t.Fatalf("client %d: got slot %d", i, res.ClientSlot)
+		}
+		clients[i] = client
+	}
+
+	// Updates reach everyone else
+	packetData, err := netmsg.Encode(&netmsg.UpdatePlayer{X: 12, Y: 34})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clients[0].SendMessage(packetData)
+	for i, client := range clients[1:] {
+		update := receive(t, client, &netmsg.UpdatePlayer{}).(*netmsg.UpdatePlayer)
+		if update.X != 12 || update.Y != 34 {
+			t.Fatalf("client %d: got update at %d, %d", i+1, update.X, update.Y)
+		}
+	}
+
+	// Everyone hears when a player leaves
+	conn, err := l.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hello, err := netmsg.Encode(&netmsg.Hello{ProtocolVersion: netmsg.ProtocolVersion})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.WriteMessage(hello)
+	buf, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, msg, err := netmsg.Decode(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slot := msg.(*netmsg.ConnectResponse).ClientSlot
+	conn.WriteClose(transport.CloseNormalClosure, "")
+	for i, client := range clients {
+		left := receive(t, client, &netmsg.DisconnectPlayer{}).(*netmsg.DisconnectPlayer)
+		if left.ClientSlot != slot {
+			t.Fatalf("client %d: got slot %d leaving, want %d", i, left.ClientSlot, slot)
+		}
+	}
+}
+
+func TestServePipeRejectsOldClients(t *testing.T) {
+	l, shutdown := startPipeServer(t, Options{})
+	defer shutdown()
+
+	conn, err := l.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	hello, err := netmsg.Encode(&netmsg.Hello{ProtocolVersion: netmsg.ProtocolVersion - 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.WriteMessage(hello)
+	buf, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, msg, err := netmsg.Decode(buf); err != nil {
+		t.Fatal(err)
+	} else if _, ok := msg.(*netmsg.Reject); !ok {
+		t.Fatalf("got %T, want *netmsg.Reject", msg)
+	}
+	_, err = conn.ReadMessage()
+	if closeErr, ok := err.(*transport.CloseError); !ok || closeErr.Code != transport.ClosePolicyViolation {
+		t.Fatalf("got %v, want close %d", err, transport.ClosePolicyViolation)
+	}
+}
+
+func TestServePipeAcceptsLongHello(t *testing.T) {
+	l, shutdown := startPipeServer(t, Options{})
+	defer shutdown()
+
+	// Names are shortened by the game, not turned away
+	conn, err := l.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	hello, err := netmsg.Encode(&netmsg.Hello{
+		ProtocolVersion: netmsg.ProtocolVersion,
+		ClientBuild:     strings.Repeat("b", 100),
+		Name:            strings.Repeat("n", 500),
+		ResumeToken:     strings.Repeat("0", 32),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(len(hello)) <= DefaultOptions().MaxMessageSize {
+		t.Fatalf("hello is only %d bytes", len(hello))
+	}
+	conn.WriteMessage(hello)
+	buf, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, msg, err := netmsg.Decode(buf); err != nil {
+		t.Fatal(err)
+	} else if _, ok := msg.(*netmsg.ConnectResponse); !ok {
+		t.Fatalf("got %T, want *netmsg.ConnectResponse", msg)
+	}
+
+	// Clients cut names that would make Hello too long
+	client := gameclient.NewClient()
+	client.SetName(strings.Repeat("n", 10*maxHelloSize))
+	if err := client.DialWith(l.Dial); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.Listen()
+	receive(t, client, &netmsg.ConnectResponse{})
+}
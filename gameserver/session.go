@@ -0,0 +1,79 @@
+package gameserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// resumeTokenSize is how many random bytes are in a resume token.
+const resumeTokenSize = 16
+
+// session is a client that lost connection, kept so they can resume.
+type session struct {
+	client  *Client
+	expires time.Time
+}
+
+func newResumeToken() (string, error) {
+	token := make([]byte, resumeTokenSize)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// SuspendClient removes a client like RemoveClient, except their slot and
+// data are kept for Options.GracePeriod. If they reconnect with their
+// resume token in that time they come back through ChRegister with Resumed
+// set, otherwise they are returned by ExpireSessions.
+func (s *Server) SuspendClient(c *Client) bool {
+	if _, ok := s.clients[c]; !ok {
+		return false
+	}
+	c.send.close()
+	delete(s.clients, c)
+
+	s.mu.Lock()
+	s.sessions[c.resumeToken] = &session{
+		client:  c,
+		expires: time.Now().Add(s.options.GracePeriod),
+	}
+	s.mu.Unlock()
+	return true
+}
+
+// ExpireSessions frees the slots of suspended clients that didn't reconnect
+// in time and returns them. It should be called regularly from the
+// goroutine that services ChRegister.
+func (s *Server) ExpireSessions() []*Client {
+	var expired []*Client
+	now := time.Now()
+	s.mu.Lock()
+	for token, session := range s.sessions {
+		if now.Before(session.expires) {
+			continue
+		}
+		delete(s.sessions, token)
+		s.clientSlots[session.client.clientSlot] = false
+		expired = append(expired, session.client)
+	}
+	s.mu.Unlock()
+	return expired
+}
+
+// resume takes the suspended session with the given token, if there is
+// one and it hasn't expired. Its slot stays taken for the new connection.
+func (s *Server) resume(token string) (*session, bool) {
+	if token == "" {
+		return nil, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[token]
+	if !ok || !time.Now().Before(session.expires) {
+		return nil, false
+	}
+	delete(s.sessions, token)
+	return session, true
+}
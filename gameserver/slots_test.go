@@ -0,0 +1,269 @@
+package gameserver
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/silbinarywolf/networkplatformer-go/gameclient"
+	"github.com/silbinarywolf/networkplatformer-go/netmsg"
+	"github.com/silbinarywolf/networkplatformer-go/transport"
+)
+
+// joinPipe connects a client to a pipe server and returns the slot from
+// its ConnectResponse. It doesn't fail the test itself, so it can be used
+// from other goroutines.
+func joinPipe(l *transport.PipeListener) (*gameclient.Client, int32, error) {
+	client := gameclient.NewClient()
+	if err := client.DialWith(l.Dial); err != nil {
+		return nil, 0, err
+	}
+	client.Listen()
+	timeout := time.After(testTimeout)
+	for {
+		select {
+		case buf := <-client.ChRecv():
+			_, msg, err := netmsg.Decode(buf)
+			if err != nil {
+				client.Close()
+				return nil, 0, err
+			}
+			if res, ok := msg.(*netmsg.ConnectResponse); ok {
+				return client, res.ClientSlot, nil
+			}
+		case <-timeout:
+			client.Close()
+			return nil, 0, errTestTimeout
+		}
+	}
+}
+
+// joinPipeWhenFree is joinPipe, trying again while the server is full.
+func joinPipeWhenFree(l *transport.PipeListener) (*gameclient.Client, int32, error) {
+	deadline := time.Now().Add(testTimeout)
+	for {
+		client, slot, err := joinPipe(l)
+		if _, full := err.(*gameclient.RejectError); !full || time.Now().After(deadline) {
+			return client, slot, err
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAllocateClientSlotConcurrently(t *testing.T) {
+	const maxClients = 8
+	s := NewServer(Options{MaxClients: maxClients})
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		slots = make(map[int32]int)
+		full  int
+	)
+	for i := 0; i < maxClients*4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slot, err := s.allocateClientSlot()
+			mu.Lock()
+			defer mu.Unlock()
+			if err == ErrNoMoreClientSlots {
+				full++
+				return
+			}
+			slots[slot]++
+		}()
+	}
+	wg.Wait()
+	if len(slots) != maxClients || full != maxClients*3 {
+		t.Fatalf("got %d slots and %d full, want %d and %d", len(slots), full, maxClients, maxClients*3)
+	}
+	for slot, count := range slots {
+		if count != 1 {
+			t.Fatalf("slot %d given out %d times", slot, count)
+		}
+	}
+
+	s.freeClientSlot(5)
+	if slot, err := s.allocateClientSlot(); err != nil || slot != 5 {
+		t.Fatalf("got slot %d, %v, want the freed slot 5", slot, err)
+	}
+}
+
+func TestAbandonClientKeepsSlotsAccounted(t *testing.T) {
+	s := NewServer(Options{MaxClients: 2})
+
+	// A new client's slot is freed
+	slot, err := s.allocateClientSlot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, _ := transport.Pipe()
+	s.abandonClient(&Client{conn: conn, clientSlot: slot}, nil)
+	if got, err := s.allocateClientSlot(); err != nil || got != slot {
+		t.Fatalf("got slot %d, %v, want the freed slot %d", got, err, slot)
+	}
+
+	// A resumed client's session is put back, and frees the slot when it
+	// expires
+	old := &Client{server: s, clientSlot: slot, resumeToken: "token", send: newSendQueue(1)}
+	s.RegisterClient(old, nil)
+	s.SuspendClient(old)
+	suspended, ok := s.resume("token")
+	if !ok {
+		t.Fatal("couldn't resume")
+	}
+	conn, _ = transport.Pipe()
+	s.abandonClient(&Client{conn: conn, clientSlot: slot, resumeToken: "token", resumed: true}, suspended)
+	suspended, ok = s.resume("token")
+	if !ok || suspended.client != old {
+		t.Fatal("session wasn't put back")
+	}
+	suspended.expires = time.Now()
+	s.sessions["token"] = suspended
+	if expired := s.ExpireSessions(); len(expired) != 1 || expired[0] != old {
+		t.Fatalf("got %v expired, want the abandoned session", expired)
+	}
+	if got, err := s.allocateClientSlot(); err != nil || got != slot {
+		t.Fatalf("got slot %d, %v, want the expired slot %d", got, err, slot)
+	}
+}
+
+func TestServerFullRejects(t *testing.T) {
+	l, shutdown := startPipeServer(t, Options{MaxClients: 2})
+	defer shutdown()
+
+	clients := make([]*gameclient.Client, 2)
+	for i := range clients {
+		client, _, err := joinPipe(l)
+		if err != nil {
+			t.Fatalf("client %d: %v", i, err)
+		}
+		defer client.Close()
+		clients[i] = client
+	}
+
+	_, _, err := joinPipe(l)
+	reject, ok := err.(*gameclient.RejectError)
+	if !ok {
+		t.Fatalf("got %v, want a *gameclient.RejectError", err)
+	}
+	if reject.Reason != "Server is full." || !reject.Temporary() {
+		t.Fatalf("got reason %q with close %d, want a full server to be worth trying again", reject.Reason, reject.Code)
+	}
+
+	// Once someone leaves there is room again
+	clients[0].Close()
+	receive(t, clients[1], &netmsg.DisconnectPlayer{})
+	client, slot, err := joinPipe(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if slot != 0 {
+		t.Fatalf("got slot %d, want the freed slot 0", slot)
+	}
+}
+
+func TestReconnectWaitsForRoom(t *testing.T) {
+	l, shutdown := startPipeServer(t, Options{MaxClients: 1})
+	defer shutdown()
+
+	var (
+		mu    sync.Mutex
+		conns []transport.Conn
+	)
+	dials := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(conns)
+	}
+	client := gameclient.NewClient()
+	client.SetReconnect(true)
+	err := client.DialWith(func() (transport.Conn, error) {
+		conn, err := l.Dial()
+		mu.Lock()
+		conns = append(conns, conn)
+		mu.Unlock()
+		return conn, err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Listen()
+	defer client.Close()
+	receive(t, client, &netmsg.ConnectResponse{})
+
+	// Lose the connection, and have someone take the slot before the
+	// client gets back
+	mu.Lock()
+	conns[0].Close()
+	mu.Unlock()
+	other, _, err := joinPipeWhenFree(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Once it has been turned away, make room
+	deadline := time.Now().Add(testTimeout)
+	for dials() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d dials, want a reconnect attempt after being turned away", dials())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	other.Close()
+
+	receive(t, client, &netmsg.ConnectResponse{})
+	select {
+	case err := <-client.ChReconnectFailed():
+		t.Fatalf("gave up reconnecting: %v", err)
+	default:
+	}
+}
+
+func TestConcurrentJoinsAndLeaves(t *testing.T) {
+	const maxClients = 4
+	l, shutdown := startPipeServer(t, Options{MaxClients: maxClients})
+	defer shutdown()
+
+	var wg sync.WaitGroup
+	for i := 0; i < maxClients*4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for round := 0; round < 5; round++ {
+				client, slot, err := joinPipeWhenFree(l)
+				if err != nil {
+					t.Errorf("client %d: %v", i, err)
+					return
+				}
+				if slot < 0 || slot >= maxClients {
+					t.Errorf("client %d: got slot %d", i, slot)
+				}
+				client.Close()
+				select {
+				case <-client.ChDisconnected():
+				case <-time.After(testTimeout):
+					t.Errorf("client %d: didn't disconnect", i)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Every slot was given back
+	seen := make(map[int32]bool)
+	for i := 0; i < maxClients; i++ {
+		client, slot, err := joinPipeWhenFree(l)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+		seen[slot] = true
+	}
+	if len(seen) != maxClients {
+		t.Fatalf("got slots %v, want %d different slots", seen, maxClients)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"time"
+)
+
+// snapshotBufferSize is how many states are kept for each remote player,
+// enough for about half a second of updates.
+const snapshotBufferSize = 32
+
+// charSnapshot is the state of a remote player at the time we received it.
+type charSnapshot struct {
+	time time.Time
+	X    float64
+	Y    float64
+	VX   float64
+	VY   float64
+}
+
+// snapshotBuffer holds the most recent states received for a remote player,
+// oldest first, so they can be drawn smoothly in between updates.
+type snapshotBuffer struct {
+	snapshots [snapshotBufferSize]charSnapshot
+	start     int
+	count     int
+}
+
+func (b *snapshotBuffer) Add(snapshot charSnapshot) {
+	if b.count == snapshotBufferSize {
+		// Full, drop the oldest
+		b.start = (b.start + 1) % snapshotBufferSize
+		b.count--
+	}
+	b.snapshots[(b.start+b.count)%snapshotBufferSize] = snapshot
+	b.count++
+}
+
+func (b *snapshotBuffer) at(i int) *charSnapshot {
+	return &b.snapshots[(b.start+i)%snapshotBufferSize]
+}
+
+// Sample returns where the player was at renderTime by interpolating
+// between the snapshots either side of it. If renderTime is newer than every
+// snapshot, because updates are late, the newest snapshot is extrapolated
+// using its velocity for up to maxExtrapolation. ok is false if the buffer
+// is empty.
+func (b *snapshotBuffer) Sample(renderTime time.Time, maxExtrapolation time.Duration) (x, y float64, ok bool) {
+	if b.count == 0 {
+		return 0, 0, false
+	}
+	oldest := b.at(0)
+	if !renderTime.After(oldest.time) {
+		return oldest.X, oldest.Y, true
+	}
+	for i := 1; i < b.count; i++ {
+		to := b.at(i)
+		if renderTime.After(to.time) {
+			continue
+		}
+		from := b.at(i - 1)
+		t := float64(renderTime.Sub(from.time)) / float64(to.time.Sub(from.time))
+		return from.X + (to.X-from.X)*t, from.Y + (to.Y-from.Y)*t, true
+	}
+
+	// Extrapolate from the newest
+	newest := b.at(b.count - 1)
+	ahead := renderTime.Sub(newest.time)
+	if ahead > maxExtrapolation {
+		ahead = maxExtrapolation
+	}
+	return newest.X + newest.VX*ahead.Seconds(), newest.Y + newest.VY*ahead.Seconds(), true
+}
@@ -0,0 +1,129 @@
+// Package latency measures round-trip time and jitter from ping messages,
+// and estimates the clock of the other side of a connection.
+package latency
+
+import (
+	"sync"
+	"time"
+)
+
+var start = time.Now()
+
+// Now is the local clock that ping times are measured with. It is
+// monotonic and counts from when the process started.
+func Now() time.Duration {
+	return time.Since(start)
+}
+
+// Tracker keeps a smoothed round-trip time and jitter, the same way TCP
+// and RTP do. It is safe to use from multiple goroutines.
+type Tracker struct {
+	mu      sync.Mutex
+	rtt     time.Duration
+	jitter  time.Duration
+	last    time.Duration
+	samples int
+}
+
+// Add records a measured round-trip time.
+func (t *Tracker) Add(rtt time.Duration) {
+	if rtt < 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.samples == 0 {
+		t.rtt = rtt
+	} else {
+		t.rtt += (rtt - t.rtt) / 8
+		diff := rtt - t.last
+		if diff < 0 {
+			diff = -diff
+		}
+		t.jitter += (diff - t.jitter) / 16
+	}
+	t.last = rtt
+	t.samples++
+}
+
+// RTT returns the smoothed round-trip time, or 0 if nothing has been
+// measured yet.
+func (t *Tracker) RTT() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.rtt
+}
+
+// Jitter returns how much the round-trip time varies from one ping to the
+// next, on average.
+func (t *Tracker) Jitter() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.jitter
+}
+
+// Clock estimates a remote clock, and the remote's simulation tick, from
+// the times it replies to pings with. It is safe to use from multiple
+// goroutines.
+type Clock struct {
+	mu sync.Mutex
+
+	// Remote clock minus local clock
+	offset time.Duration
+	synced bool
+
+	// The remote's tick at remote time tickTime
+	tick     uint64
+	tickTime time.Duration
+}
+
+// Add records a pong. remoteTime and remoteTick are when the remote replied,
+// sent and received are the local times the ping was sent and the pong
+// received. The remote is assumed to have replied halfway between them.
+func (c *Clock) Add(remoteTime time.Duration, remoteTick uint64, sent, received time.Duration) {
+	offset := remoteTime - (sent + (received-sent)/2)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.synced {
+		c.offset = offset
+		c.synced = true
+	} else {
+		c.offset += (offset - c.offset) / 8
+	}
+	if remoteTick > c.tick || c.tickTime == 0 {
+		c.tick = remoteTick
+		c.tickTime = remoteTime
+	}
+}
+
+// Reset forgets everything, such as after connecting to another server.
+func (c *Clock) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.offset = 0
+	c.synced = false
+	c.tick = 0
+	c.tickTime = 0
+}
+
+// Now returns the estimated remote time, if any pongs have been received.
+func (c *Clock) Now() (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Now() + c.offset, c.synced
+}
+
+// Tick returns the estimated remote tick, for a remote that ticks every
+// tickDuration.
+func (c *Clock) Tick(tickDuration time.Duration) (uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.synced {
+		return 0, false
+	}
+	elapsed := Now() + c.offset - c.tickTime
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return c.tick + uint64(elapsed/tickDuration), true
+}
@@ -0,0 +1,142 @@
+// Package level loads tile maps that describe the solid parts of the world
+// and where players spawn.
+package level
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Size of a tile, in pixels
+const TileSize = 32
+
+const (
+	tileEmpty = '.'
+	tileSolid = '#'
+	tileSpawn = 'S'
+)
+
+var (
+	ErrNotFound = errors.New("Level not found.")
+	ErrNoSpawns = errors.New("Level has no spawn points.")
+)
+
+// Spawn is where a player can enter the level. X and Y are the bottom-left
+// corner of the spawn tile, which is where a character's feet go.
+type Spawn struct {
+	X float64
+	Y float64
+}
+
+type Level struct {
+	Name string
+
+	// Size of the level in tiles
+	Width  int
+	Height int
+
+	Spawns []Spawn
+
+	solid []bool
+}
+
+// Load returns the built-in level with the given name.
+func Load(name string) (*Level, error) {
+	data, ok := levels[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return Parse(name, data)
+}
+
+// Names returns the names of the built-in levels.
+func Names() []string {
+	names := make([]string, 0, len(levels))
+	for name := range levels {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Parse reads a level from a text grid where each character is one tile:
+//
+//	. empty
+//	# solid
+//	S empty, players can spawn here
+//
+// Blank lines before and after the grid are ignored and every row must be
+// the same width.
+func Parse(name string, data string) (*Level, error) {
+	rows := strings.Split(strings.TrimSpace(data), "\n")
+	l := &Level{
+		Name:   name,
+		Width:  len(strings.TrimSpace(rows[0])),
+		Height: len(rows),
+	}
+	l.solid = make([]bool, l.Width*l.Height)
+	for y, row := range rows {
+		row = strings.TrimSpace(row)
+		if len(row) != l.Width {
+			return nil, fmt.Errorf("level %s: row %d is %d tiles wide, expected %d", name, y, len(row), l.Width)
+		}
+		for x, tile := range row {
+			switch tile {
+			case tileEmpty:
+			case tileSolid:
+				l.solid[y*l.Width+x] = true
+			case tileSpawn:
+				l.Spawns = append(l.Spawns, Spawn{
+					X: float64(x * TileSize),
+					Y: float64((y + 1) * TileSize),
+				})
+			default:
+				return nil, fmt.Errorf("level %s: unknown tile %q at %d,%d", name, tile, x, y)
+			}
+		}
+	}
+	if len(l.Spawns) == 0 {
+		return nil, ErrNoSpawns
+	}
+	return l, nil
+}
+
+// PixelWidth returns the width of the level in pixels.
+func (l *Level) PixelWidth() float64 { return float64(l.Width * TileSize) }
+
+// PixelHeight returns the height of the level in pixels.
+func (l *Level) PixelHeight() float64 { return float64(l.Height * TileSize) }
+
+// IsSolid reports whether the tile at tx, ty is solid. Everything outside
+// the level is solid, so the edges of the level act as walls.
+func (l *Level) IsSolid(tx, ty int) bool {
+	if tx < 0 || ty < 0 || tx >= l.Width || ty >= l.Height {
+		return true
+	}
+	return l.solid[ty*l.Width+tx]
+}
+
+// Collides reports whether the box at x, y with the given size overlaps
+// any solid tile. Touching the edge of a tile doesn't count as overlapping.
+func (l *Level) Collides(x, y, width, height float64) bool {
+	left, top, right, bottom := TileBounds(x, y, width, height)
+	for ty := top; ty <= bottom; ty++ {
+		for tx := left; tx <= right; tx++ {
+			if l.IsSolid(tx, ty) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TileBounds returns the range of tiles that the box at x, y with the
+// given size overlaps.
+func TileBounds(x, y, width, height float64) (left, top, right, bottom int) {
+	left = int(math.Floor(x / TileSize))
+	top = int(math.Floor(y / TileSize))
+	right = int(math.Ceil((x+width)/TileSize)) - 1
+	bottom = int(math.Ceil((y+height)/TileSize)) - 1
+	return
+}
@@ -0,0 +1,41 @@
+package level
+
+// levels are the built-in levels, by name. See Parse for the format.
+var levels = map[string]string{
+	"default": `
+................................
+................................
+................................
+................................
+................................
+.......................#####....
+................................
+................................
+..............#####.............
+................................
+................................
+......#####...........#####.....
+................................
+..S.......S.......S........S....
+################################
+################################
+`,
+	"flat": `
+................................
+................................
+................................
+................................
+................................
+................................
+................................
+................................
+................................
+................................
+................................
+................................
+................................
+..S.......S.......S........S....
+################################
+################################
+`,
+}
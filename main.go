@@ -16,15 +16,23 @@ package main
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
 	"image"
+	"image/color"
 	_ "image/png"
-	"os"
+	"log"
 	"time"
 
 	"github.com/hajimehoshi/ebiten"
 	"github.com/hajimehoshi/ebiten/ebitenutil"
 	rplatformer "github.com/hajimehoshi/ebiten/examples/resources/images/platformer"
+	"github.com/hajimehoshi/ebiten/text"
+	"github.com/silbinarywolf/networkplatformer-go/game"
+	"github.com/silbinarywolf/networkplatformer-go/gameclient"
+	"github.com/silbinarywolf/networkplatformer-go/level"
+	"github.com/silbinarywolf/networkplatformer-go/netsim"
+	"golang.org/x/image/font/basicfont"
 )
 
 const (
@@ -40,8 +48,8 @@ var (
 	backgroundImage *ebiten.Image
 )
 
-func init() {
-	// Preload images
+// loadImages decodes the sprites and background.
+func loadImages() {
 	img, _, err := image.Decode(bytes.NewReader(rplatformer.Right_png))
 	if err != nil {
 		panic(err)
@@ -67,70 +75,61 @@ func init() {
 	backgroundImage, _ = ebiten.NewImageFromImage(img, ebiten.FilterDefault)
 }
 
-type Char struct {
-	X                 float64
-	Y                 float64
-	sprite            *ebiten.Image
-	isKeyLeftPressed  bool
-	isKeyRightPressed bool
+var (
+	you   *Char   = &Char{}
+	chars []*Char = make([]*Char, 0, 256)
 
-	// used by server only
-	lastUpdatedTimer time.Time
-}
+	// The level being played. The server picks it and tells us when we
+	// connect.
+	currentLevel *level.Level
 
-func (c *Char) RemoveFromSimulation() {
-	// Unordered remove
-	for i, char := range chars {
-		if char == c {
-			chars[i] = chars[len(chars)-1] // Replace it with the last one.
-			chars = chars[:len(chars)-1]   // delete last element
-			return
-		}
-	}
-}
+	solidTileColor = color.RGBA{0x30, 0x24, 0x1c, 0xff}
 
-var (
-	you *Char = &Char{
-		X: 50,
-		Y: 380,
-	}
-	chars []*Char = make([]*Char, 0, 256)
+	// Font of the names above players. It is fixed width, which makes
+	// centering them simple.
+	nameplateFace = basicfont.Face7x13
+
+	// Decides how many ticks to simulate each frame
+	frameTicks game.TickAccumulator
 )
 
 func update(screen *ebiten.Image) error {
 	// Read/write network information
-	if server != nil {
-		server.Update()
-	}
 	if client != nil {
 		client.Update()
 	}
 
-	// Controls
+	// Chat
+	if message := chat.Update(); message != "" && client != nil {
+		client.SendChat(message)
+	}
+
+	// Controls, ignored while typing a chat message
 	if you != nil {
-		you.isKeyLeftPressed = false
-		you.isKeyRightPressed = false
-		if ebiten.IsKeyPressed(ebiten.KeyA) || ebiten.IsKeyPressed(ebiten.KeyLeft) {
-			you.isKeyLeftPressed = true
-		} else if ebiten.IsKeyPressed(ebiten.KeyD) || ebiten.IsKeyPressed(ebiten.KeyRight) {
-			you.isKeyRightPressed = true
+		you.IsKeyLeftPressed = false
+		you.IsKeyRightPressed = false
+		you.IsKeyJumpPressed = false
+		if !chat.typing {
+			if ebiten.IsKeyPressed(ebiten.KeyA) || ebiten.IsKeyPressed(ebiten.KeyLeft) {
+				you.IsKeyLeftPressed = true
+			} else if ebiten.IsKeyPressed(ebiten.KeyD) || ebiten.IsKeyPressed(ebiten.KeyRight) {
+				you.IsKeyRightPressed = true
+			}
+			you.IsKeyJumpPressed = ebiten.IsKeyPressed(ebiten.KeySpace) ||
+				ebiten.IsKeyPressed(ebiten.KeyW) ||
+				ebiten.IsKeyPressed(ebiten.KeyUp)
 		}
 	}
 
-	// Simulate
-	for _, char := range chars {
-		char.sprite = idleSprite
-		if char.isKeyLeftPressed {
-			// Selects preloaded sprite
-			char.sprite = leftSprite
-			// Moves character 3px right
-			char.X -= 3
-		} else if char.isKeyRightPressed {
-			// Selects preloaded sprite
-			char.sprite = rightSprite
-			// Moves character 3px left
-			char.X += 3
+	// Simulate at a fixed rate, independent of the frame rate
+	for ticks := frameTicks.Ticks(time.Now()); ticks > 0; ticks-- {
+		if client != nil {
+			client.SendInput()
 		}
+		Step(game.TickDuration.Seconds())
+	}
+	if client != nil {
+		client.Interpolate()
 	}
 
 	if ebiten.IsRunningSlowly() {
@@ -142,46 +141,121 @@ func update(screen *ebiten.Image) error {
 	op.GeoM.Scale(0.5, 0.5)
 	screen.DrawImage(backgroundImage, op)
 
+	// Draws solid tiles
+	if currentLevel != nil {
+		for ty := 0; ty < currentLevel.Height; ty++ {
+			for tx := 0; tx < currentLevel.Width; tx++ {
+				if !currentLevel.IsSolid(tx, ty) {
+					continue
+				}
+				x := float64(tx * level.TileSize)
+				y := float64(ty * level.TileSize)
+				ebitenutil.DrawRect(screen, x, y, level.TileSize, level.TileSize, solidTileColor)
+			}
+		}
+	}
+
 	// Draws selected sprite image
 	for _, char := range chars {
-		if char.sprite == nil {
-			continue
+		// Selects preloaded sprite
+		sprite := idleSprite
+		if char.IsKeyLeftPressed {
+			sprite = leftSprite
+		} else if char.IsKeyRightPressed {
+			sprite = rightSprite
 		}
 		op = &ebiten.DrawImageOptions{}
 		op.GeoM.Scale(0.5, 0.5)
 		op.GeoM.Translate(char.X, char.Y)
-		screen.DrawImage(char.sprite, op)
+		if char.connectionInterrupted {
+			// Faded until they reconnect
+			op.ColorM.Scale(1, 1, 1, 0.5)
+		}
+		screen.DrawImage(sprite, op)
+	}
+
+	// Draws names centered above each character
+	for _, char := range chars {
+		label := char.Name
+		if char.connectionInterrupted {
+			label += " (connection interrupted)"
+		}
+		if label == "" {
+			continue
+		}
+		x := int(char.X) + game.CharWidth/2 - len(label)*nameplateFace.Advance/2
+		y := int(char.Y) - nameplateFace.Descent - 2
+		text.Draw(screen, label, nameplateFace, x, y, color.White)
 	}
 
-	// FPS counter
-	fps := fmt.Sprintf("FPS: %f", ebiten.CurrentFPS())
-	ebitenutil.DebugPrint(screen, fps)
+	chat.Draw(screen)
+
+	// FPS counter and network stats
+	debugText := fmt.Sprintf("FPS: %f", ebiten.CurrentFPS())
+	if client != nil && isConnected {
+		debugText += fmt.Sprintf("\nPing: %dms (jitter %dms, server sees %dms)",
+			client.RTT()/time.Millisecond,
+			client.Jitter()/time.Millisecond,
+			client.ServerRTT()/time.Millisecond)
+		if serverTick, ok := client.ServerTick(game.TickDuration); ok {
+			debugText += fmt.Sprintf("\nTick: %d (server ~%d)", currentTick, serverTick)
+		}
+	}
+	ebitenutil.DebugPrint(screen, debugText)
 
 	return nil
 }
 
 func main() {
+	var (
+		host               string
+		path               string
+		name               string
+		interpolationDelay time.Duration
+		maxExtrapolation   time.Duration
+		netSim             netsim.Config
+		isServer           bool
+	)
+	flag.BoolVar(&isServer, "server", false, "No longer runs a server, see cmd/server")
+	flag.StringVar(&host, "host", "localhost:8080", "Server address the client connects to")
+	flag.StringVar(&path, "path", "/ws", "Path of the server's websocket endpoint")
+	flag.StringVar(&name, "name", "", "Name shown to other players")
+	flag.DurationVar(&interpolationDelay, "interp-delay", 100*time.Millisecond, "How far behind the latest update other players are drawn")
+	flag.DurationVar(&maxExtrapolation, "extrapolate", 50*time.Millisecond, "How far other players are predicted ahead when updates are late, 0 to disable")
+	flag.DurationVar(&netSim.Latency, "sim-latency", 0, "Simulated delay added to every message sent and received")
+	flag.DurationVar(&netSim.Jitter, "sim-jitter", 0, "Simulated messages are delayed up to this much more or less than -sim-latency")
+	flag.Float64Var(&netSim.Loss, "sim-loss", 0, "Chance of a simulated message being dropped, from 0 to 1")
+	flag.Float64Var(&netSim.Duplicate, "sim-dup", 0, "Chance of a simulated message being delivered twice, from 0 to 1")
+	flag.Float64Var(&netSim.Reorder, "sim-reorder", 0, "Chance of a simulated message overtaking earlier ones, from 0 to 1")
+	flag.Parse()
+
+	if isServer {
+		// Old launch commands still say where the server went
+		log.Fatal("The dedicated server is its own command now, run it with: go build ./cmd/server && ./server.exe")
+	}
+	if err := netSim.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
 	// Setup network
-	isServer := false
-	if len(os.Args) > 1 {
-		firstArg := os.Args[1]
-		if firstArg == "--server" {
-			isServer = true
-		}
+	loadImages()
+	client = NewClient()
+	client.SetPath(path)
+	client.SetName(name)
+	client.SetReconnect(true)
+	client.SetNetSim(netSim)
+	client.InterpolationDelay = interpolationDelay
+	client.MaxExtrapolation = maxExtrapolation
+	err := client.Dial(host)
+	if err, ok := err.(*gameclient.RejectError); ok {
+		log.Fatalf("Can't join server: %s", err.Reason)
 	}
-	if isServer {
-		server = NewServer()
-		go server.Listen()
-	} else {
-		client = NewClient()
-		err := client.Dial("localhost:8080")
-		if err != nil {
-			panic(err)
-		}
-		go client.Listen()
+	if err != nil {
+		panic(err)
 	}
+	go client.Listen()
 
-	// This is required so the server can run when the window isn't focused.
+	// This is required so the client keeps talking to the server when the window isn't focused.
 	ebiten.SetRunnableInBackground(true)
 
 	if err := ebiten.Run(update, screenWidth, screenHeight, 1, "Platformer (Ebiten Demo)"); err != nil {
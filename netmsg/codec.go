@@ -0,0 +1,96 @@
+package netmsg
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/gogo/protobuf/proto"
+)
+
+var (
+	ErrEmptyPacket      = errors.New("Packet has no message kind.")
+	ErrUnregisteredType = errors.New("Message type has not been registered.")
+)
+
+// Message is any message that can be sent over the network. Its type has
+// to be registered with a Kind before it can be encoded or decoded.
+type Message interface {
+	proto.Message
+}
+
+// UnknownKindError is returned when decoding a packet whose kind has not
+// been registered.
+type UnknownKindError struct {
+	Kind Kind
+}
+
+func (err *UnknownKindError) Error() string {
+	return fmt.Sprintf("Unknown message kind: %d", byte(err.Kind))
+}
+
+var (
+	kindToType = make(map[Kind]reflect.Type)
+	typeToKind = make(map[reflect.Type]Kind)
+)
+
+// Register associates a message type with the kind byte it is sent with.
+// msg must be a pointer to a message, such as &ConnectResponse{}. It
+// panics if the kind or type is already registered.
+func Register(kind Kind, msg Message) {
+	t := reflect.TypeOf(msg)
+	if t == nil || t.Kind() != reflect.Ptr {
+		panic(fmt.Sprintf("netmsg: %s must be registered with a pointer, not %T", kind, msg))
+	}
+	if kind == MsgUnknown {
+		panic(fmt.Sprintf("netmsg: %T can't be registered as %s", msg, kind))
+	}
+	if other, ok := kindToType[kind]; ok {
+		panic(fmt.Sprintf("netmsg: %s is already registered to %s", kind, other))
+	}
+	if other, ok := typeToKind[t]; ok {
+		panic(fmt.Sprintf("netmsg: %s is already registered as %s", t, other))
+	}
+	kindToType[kind] = t
+	typeToKind[t] = kind
+}
+
+// KindOf returns the kind msg is sent with.
+func KindOf(msg Message) (Kind, bool) {
+	kind, ok := typeToKind[reflect.TypeOf(msg)]
+	return kind, ok
+}
+
+// Encode marshals msg into a packet, prefixed with its kind byte.
+func Encode(msg Message) ([]byte, error) {
+	kind, ok := KindOf(msg)
+	if !ok {
+		return nil, ErrUnregisteredType
+	}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	packetData := make([]byte, 1, len(data)+1)
+	packetData[0] = byte(kind)
+	packetData = append(packetData, data...)
+	return packetData, nil
+}
+
+// Decode unmarshals a packet made by Encode into a new message of the
+// registered type.
+func Decode(packetData []byte) (Kind, Message, error) {
+	if len(packetData) == 0 {
+		return MsgUnknown, nil, ErrEmptyPacket
+	}
+	kind := Kind(packetData[0])
+	t, ok := kindToType[kind]
+	if !ok {
+		return kind, nil, &UnknownKindError{Kind: kind}
+	}
+	msg := reflect.New(t.Elem()).Interface().(Message)
+	if err := proto.Unmarshal(packetData[1:], msg); err != nil {
+		return kind, nil, fmt.Errorf("%s: %v", kind, err)
+	}
+	return kind, msg, nil
+}
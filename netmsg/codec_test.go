@@ -0,0 +1,73 @@
+package netmsg
+
+import (
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	for _, msg := range []Message{
+		&ConnectResponse{ClientSlot: 3, X: -100, Y: 200, MaxClients: 8, Level: "default", Name: "Bob"},
+		&UpdatePlayer{InputSequence: 7, X: 1, Y: -1, IsKeyRightPressed: true},
+		&ChatMessage{ClientSlot: 1, Name: "Alice", Text: "Hi"},
+		// Every field at zero encodes to just the kind byte
+		&Ping{},
+	} {
+		packetData, err := Encode(msg)
+		if err != nil {
+			t.Fatalf("%T: %v", msg, err)
+		}
+		wantKind, _ := KindOf(msg)
+		kind, got, err := Decode(packetData)
+		if err != nil {
+			t.Fatalf("%T: %v", msg, err)
+		}
+		if kind != wantKind {
+			t.Fatalf("%T: got %s, want %s", msg, kind, wantKind)
+		}
+		if !proto.Equal(got, msg) {
+			t.Fatalf("%T: got %v, want %v", msg, got, msg)
+		}
+	}
+}
+
+func TestEncodeUnregisteredType(t *testing.T) {
+	if _, err := Encode(&PlayerState{}); err != ErrUnregisteredType {
+		t.Fatalf("got %v, want %v", err, ErrUnregisteredType)
+	}
+}
+
+func TestDecodeUnknownKind(t *testing.T) {
+	for _, kind := range []Kind{MsgUnknown, MsgPong + 1, 255} {
+		_, msg, err := Decode([]byte{byte(kind), 0x08, 0x01})
+		unknown, ok := err.(*UnknownKindError)
+		if !ok || unknown.Kind != kind {
+			t.Fatalf("kind %d: got %v, want an UnknownKindError", byte(kind), err)
+		}
+		if msg != nil {
+			t.Fatalf("kind %d: got a %T as well as an error", byte(kind), msg)
+		}
+	}
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	if _, _, err := Decode(nil); err != ErrEmptyPacket {
+		t.Fatalf("got %v, want %v", err, ErrEmptyPacket)
+	}
+
+	packetData, err := Encode(&ChatMessage{ClientSlot: 1, Name: "Alice", Text: "Hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Cutting into the strings leaves them shorter than their length says
+	for _, n := range []int{len(packetData) - 1, len(packetData) - 3} {
+		kind, msg, err := Decode(packetData[:n])
+		if err == nil {
+			t.Fatalf("%d of %d bytes: decoded %v", n, len(packetData), msg)
+		}
+		if kind != MsgChatMessage {
+			t.Fatalf("%d of %d bytes: got %s, want %s", n, len(packetData), kind, MsgChatMessage)
+		}
+	}
+}
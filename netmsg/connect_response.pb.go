@@ -1,24 +1,15 @@
 // Code generated by protoc-gen-gogo. DO NOT EDIT.
 // source: connect_response.proto
 
-/*
-	Package netmsg is a generated protocol buffer package.
-
-	It is generated from these files:
-		connect_response.proto
-
-	It has these top-level messages:
-		ConnectResponse
-*/
 package netmsg
 
-import proto "github.com/golang/protobuf/proto"
-import fmt "fmt"
-import math "math"
-
-import binary "encoding/binary"
-
-import io "io"
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	io "io"
+	math "math"
+	math_bits "math/bits"
+)
 
 // Reference imports to suppress errors if they are not otherwise used.
 var _ = proto.Marshal
@@ -29,18 +20,59 @@ var _ = math.Inf
 // is compatible with the proto package it is being compiled against.
 // A compilation error at this line likely means your copy of the
 // proto package needs to be updated.
-const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
 type ConnectResponse struct {
-	ClientSlot int32   `protobuf:"varint,1,opt,name=ClientSlot,proto3" json:"ClientSlot,omitempty"`
-	X          float64 `protobuf:"fixed64,2,opt,name=X,proto3" json:"X,omitempty"`
-	Y          float64 `protobuf:"fixed64,3,opt,name=Y,proto3" json:"Y,omitempty"`
+	ClientSlot int32 `protobuf:"varint,1,opt,name=ClientSlot,proto3" json:"ClientSlot,omitempty"`
+	// Positions are fixed-point with PositionPrecision fractional bits
+	X          int32  `protobuf:"zigzag32,2,opt,name=X,proto3" json:"X,omitempty"`
+	Y          int32  `protobuf:"zigzag32,3,opt,name=Y,proto3" json:"Y,omitempty"`
+	MaxClients int32  `protobuf:"varint,4,opt,name=MaxClients,proto3" json:"MaxClients,omitempty"`
+	Level      string `protobuf:"bytes,5,opt,name=Level,proto3" json:"Level,omitempty"`
+	// The server's current simulation tick
+	Tick              uint64 `protobuf:"varint,6,opt,name=Tick,proto3" json:"Tick,omitempty"`
+	PositionPrecision uint32 `protobuf:"varint,7,opt,name=PositionPrecision,proto3" json:"PositionPrecision,omitempty"`
+	// Our name, after the server has validated it
+	Name string `protobuf:"bytes,8,opt,name=Name,proto3" json:"Name,omitempty"`
+	// Sent back in Hello to resume this session after reconnecting
+	ResumeToken          string   `protobuf:"bytes,9,opt,name=ResumeToken,proto3" json:"ResumeToken,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *ConnectResponse) Reset()                    { *m = ConnectResponse{} }
-func (m *ConnectResponse) String() string            { return proto.CompactTextString(m) }
-func (*ConnectResponse) ProtoMessage()               {}
-func (*ConnectResponse) Descriptor() ([]byte, []int) { return fileDescriptorConnectResponse, []int{0} }
+func (m *ConnectResponse) Reset()         { *m = ConnectResponse{} }
+func (m *ConnectResponse) String() string { return proto.CompactTextString(m) }
+func (*ConnectResponse) ProtoMessage()    {}
+func (*ConnectResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d32d67fa16447f89, []int{0}
+}
+func (m *ConnectResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ConnectResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ConnectResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ConnectResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ConnectResponse.Merge(m, src)
+}
+func (m *ConnectResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *ConnectResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ConnectResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ConnectResponse proto.InternalMessageInfo
 
 func (m *ConnectResponse) GetClientSlot() int32 {
 	if m != nil {
@@ -49,27 +81,92 @@ func (m *ConnectResponse) GetClientSlot() int32 {
 	return 0
 }
 
-func (m *ConnectResponse) GetX() float64 {
+func (m *ConnectResponse) GetX() int32 {
 	if m != nil {
 		return m.X
 	}
 	return 0
 }
 
-func (m *ConnectResponse) GetY() float64 {
+func (m *ConnectResponse) GetY() int32 {
 	if m != nil {
 		return m.Y
 	}
 	return 0
 }
 
+func (m *ConnectResponse) GetMaxClients() int32 {
+	if m != nil {
+		return m.MaxClients
+	}
+	return 0
+}
+
+func (m *ConnectResponse) GetLevel() string {
+	if m != nil {
+		return m.Level
+	}
+	return ""
+}
+
+func (m *ConnectResponse) GetTick() uint64 {
+	if m != nil {
+		return m.Tick
+	}
+	return 0
+}
+
+func (m *ConnectResponse) GetPositionPrecision() uint32 {
+	if m != nil {
+		return m.PositionPrecision
+	}
+	return 0
+}
+
+func (m *ConnectResponse) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ConnectResponse) GetResumeToken() string {
+	if m != nil {
+		return m.ResumeToken
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*ConnectResponse)(nil), "netmsg.ConnectResponse")
 }
+
+func init() { proto.RegisterFile("connect_response.proto", fileDescriptor_d32d67fa16447f89) }
+
+var fileDescriptor_d32d67fa16447f89 = []byte{
+	// 242 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x64, 0x90, 0xc1, 0x4a, 0x03, 0x31,
+	0x10, 0x86, 0x1d, 0xdd, 0x5d, 0x6d, 0x54, 0xb4, 0x41, 0x64, 0x4e, 0x21, 0x78, 0xca, 0x41, 0xbc,
+	0xf8, 0x06, 0xf6, 0xaa, 0x52, 0x62, 0x0f, 0xed, 0x49, 0xea, 0x32, 0x48, 0xe8, 0x6e, 0xa6, 0x6c,
+	0xa2, 0xf8, 0x28, 0x3e, 0x92, 0x47, 0x1f, 0x41, 0xd6, 0xc7, 0xf0, 0x22, 0x9b, 0x08, 0x16, 0x7a,
+	0x9b, 0xff, 0xff, 0xbf, 0xff, 0x3f, 0x8c, 0x38, 0xaf, 0xd9, 0x7b, 0xaa, 0xe3, 0x63, 0x47, 0x61,
+	0xcd, 0x3e, 0xd0, 0xd5, 0xba, 0xe3, 0xc8, 0xb2, 0xf2, 0x14, 0xdb, 0xf0, 0x7c, 0xf1, 0x03, 0xe2,
+	0x64, 0x92, 0x11, 0xfb, 0x47, 0x48, 0x25, 0xc4, 0xa4, 0x71, 0xe4, 0xe3, 0x43, 0xc3, 0x11, 0x41,
+	0x83, 0x29, 0xed, 0x86, 0x23, 0x8f, 0x04, 0xcc, 0x71, 0x57, 0x83, 0x19, 0x5b, 0x98, 0x0f, 0x6a,
+	0x81, 0x7b, 0x59, 0x2d, 0x86, 0xee, 0xdd, 0xf2, 0x2d, 0xc3, 0x01, 0x8b, 0xdc, 0xfd, 0x77, 0xe4,
+	0x99, 0x28, 0x6f, 0xe9, 0x95, 0x1a, 0x2c, 0x35, 0x98, 0x91, 0xcd, 0x42, 0x4a, 0x51, 0xcc, 0x5c,
+	0xbd, 0xc2, 0x4a, 0x83, 0x29, 0x6c, 0xba, 0xe5, 0xa5, 0x18, 0x4f, 0x39, 0xb8, 0xe8, 0xd8, 0x4f,
+	0x3b, 0xaa, 0x5d, 0x70, 0xec, 0x71, 0x5f, 0x83, 0x39, 0xb6, 0xdb, 0xc1, 0xb0, 0x70, 0xbf, 0x6c,
+	0x09, 0x0f, 0xd2, 0x6c, 0xba, 0xa5, 0x16, 0x87, 0x96, 0xc2, 0x4b, 0x4b, 0x33, 0x5e, 0x91, 0xc7,
+	0x51, 0x8a, 0x36, 0xad, 0x9b, 0xd3, 0x8f, 0x5e, 0xc1, 0x67, 0xaf, 0xe0, 0xab, 0x57, 0xf0, 0xfe,
+	0xad, 0x76, 0x9e, 0xaa, 0xf4, 0x9e, 0xeb, 0xdf, 0x01, 0x00, 0xee, 0xae, 0x26, 0x2b, 0x38, 0x01,
+	0x00, 0x00,
+}
+
 func (m *ConnectResponse) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
-	n, err := m.MarshalTo(dAtA)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
 	if err != nil {
 		return nil, err
 	}
@@ -77,63 +174,128 @@ func (m *ConnectResponse) Marshal() (dAtA []byte, err error) {
 }
 
 func (m *ConnectResponse) MarshalTo(dAtA []byte) (int, error) {
-	var i int
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ConnectResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
 	_ = i
 	var l int
 	_ = l
-	if m.ClientSlot != 0 {
-		dAtA[i] = 0x8
-		i++
-		i = encodeVarintConnectResponse(dAtA, i, uint64(m.ClientSlot))
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	if m.X != 0 {
-		dAtA[i] = 0x11
-		i++
-		binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.X))))
-		i += 8
+	if len(m.ResumeToken) > 0 {
+		i -= len(m.ResumeToken)
+		copy(dAtA[i:], m.ResumeToken)
+		i = encodeVarintConnectResponse(dAtA, i, uint64(len(m.ResumeToken)))
+		i--
+		dAtA[i] = 0x4a
+	}
+	if len(m.Name) > 0 {
+		i -= len(m.Name)
+		copy(dAtA[i:], m.Name)
+		i = encodeVarintConnectResponse(dAtA, i, uint64(len(m.Name)))
+		i--
+		dAtA[i] = 0x42
+	}
+	if m.PositionPrecision != 0 {
+		i = encodeVarintConnectResponse(dAtA, i, uint64(m.PositionPrecision))
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.Tick != 0 {
+		i = encodeVarintConnectResponse(dAtA, i, uint64(m.Tick))
+		i--
+		dAtA[i] = 0x30
+	}
+	if len(m.Level) > 0 {
+		i -= len(m.Level)
+		copy(dAtA[i:], m.Level)
+		i = encodeVarintConnectResponse(dAtA, i, uint64(len(m.Level)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.MaxClients != 0 {
+		i = encodeVarintConnectResponse(dAtA, i, uint64(m.MaxClients))
+		i--
+		dAtA[i] = 0x20
 	}
 	if m.Y != 0 {
-		dAtA[i] = 0x19
-		i++
-		binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.Y))))
-		i += 8
+		i = encodeVarintConnectResponse(dAtA, i, uint64((uint32(m.Y)<<1)^uint32((m.Y>>31))))
+		i--
+		dAtA[i] = 0x18
 	}
-	return i, nil
+	if m.X != 0 {
+		i = encodeVarintConnectResponse(dAtA, i, uint64((uint32(m.X)<<1)^uint32((m.X>>31))))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.ClientSlot != 0 {
+		i = encodeVarintConnectResponse(dAtA, i, uint64(m.ClientSlot))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
 }
 
 func encodeVarintConnectResponse(dAtA []byte, offset int, v uint64) int {
+	offset -= sovConnectResponse(v)
+	base := offset
 	for v >= 1<<7 {
 		dAtA[offset] = uint8(v&0x7f | 0x80)
 		v >>= 7
 		offset++
 	}
 	dAtA[offset] = uint8(v)
-	return offset + 1
+	return base
 }
 func (m *ConnectResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
 	var l int
 	_ = l
 	if m.ClientSlot != 0 {
 		n += 1 + sovConnectResponse(uint64(m.ClientSlot))
 	}
 	if m.X != 0 {
-		n += 9
+		n += 1 + sozConnectResponse(uint64(m.X))
 	}
 	if m.Y != 0 {
-		n += 9
+		n += 1 + sozConnectResponse(uint64(m.Y))
+	}
+	if m.MaxClients != 0 {
+		n += 1 + sovConnectResponse(uint64(m.MaxClients))
+	}
+	l = len(m.Level)
+	if l > 0 {
+		n += 1 + l + sovConnectResponse(uint64(l))
+	}
+	if m.Tick != 0 {
+		n += 1 + sovConnectResponse(uint64(m.Tick))
+	}
+	if m.PositionPrecision != 0 {
+		n += 1 + sovConnectResponse(uint64(m.PositionPrecision))
+	}
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovConnectResponse(uint64(l))
+	}
+	l = len(m.ResumeToken)
+	if l > 0 {
+		n += 1 + l + sovConnectResponse(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
 	}
 	return n
 }
 
 func sovConnectResponse(x uint64) (n int) {
-	for {
-		n++
-		x >>= 7
-		if x == 0 {
-			break
-		}
-	}
-	return n
+	return (math_bits.Len64(x|1) + 6) / 7
 }
 func sozConnectResponse(x uint64) (n int) {
 	return sovConnectResponse(uint64((x << 1) ^ uint64((int64(x) >> 63))))
@@ -153,7 +315,7 @@ func (m *ConnectResponse) Unmarshal(dAtA []byte) error {
 			}
 			b := dAtA[iNdEx]
 			iNdEx++
-			wire |= (uint64(b) & 0x7F) << shift
+			wire |= uint64(b&0x7F) << shift
 			if b < 0x80 {
 				break
 			}
@@ -181,45 +343,219 @@ func (m *ConnectResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ClientSlot |= (int32(b) & 0x7F) << shift
+				m.ClientSlot |= int32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 2:
-			if wireType != 1 {
+			if wireType != 0 {
 				return fmt.Errorf("proto: wrong wireType = %d for field X", wireType)
 			}
-			var v uint64
-			if (iNdEx + 8) > l {
-				return io.ErrUnexpectedEOF
+			var v int32
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConnectResponse
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			v = uint64(binary.LittleEndian.Uint64(dAtA[iNdEx:]))
-			iNdEx += 8
-			m.X = float64(math.Float64frombits(v))
+			v = int32((uint32(v) >> 1) ^ uint32(((v&1)<<31)>>31))
+			m.X = v
 		case 3:
-			if wireType != 1 {
+			if wireType != 0 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Y", wireType)
 			}
-			var v uint64
-			if (iNdEx + 8) > l {
+			var v int32
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConnectResponse
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			v = int32((uint32(v) >> 1) ^ uint32(((v&1)<<31)>>31))
+			m.Y = v
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxClients", wireType)
+			}
+			m.MaxClients = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConnectResponse
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxClients |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Level", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConnectResponse
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthConnectResponse
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthConnectResponse
+			}
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			v = uint64(binary.LittleEndian.Uint64(dAtA[iNdEx:]))
-			iNdEx += 8
-			m.Y = float64(math.Float64frombits(v))
+			m.Level = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Tick", wireType)
+			}
+			m.Tick = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConnectResponse
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Tick |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PositionPrecision", wireType)
+			}
+			m.PositionPrecision = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConnectResponse
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PositionPrecision |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConnectResponse
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthConnectResponse
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthConnectResponse
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Name = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ResumeToken", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConnectResponse
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthConnectResponse
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthConnectResponse
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ResumeToken = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipConnectResponse(dAtA[iNdEx:])
 			if err != nil {
 				return err
 			}
-			if skippy < 0 {
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
 				return ErrInvalidLengthConnectResponse
 			}
 			if (iNdEx + skippy) > l {
 				return io.ErrUnexpectedEOF
 			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
 			iNdEx += skippy
 		}
 	}
@@ -232,6 +568,7 @@ func (m *ConnectResponse) Unmarshal(dAtA []byte) error {
 func skipConnectResponse(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
+	depth := 0
 	for iNdEx < l {
 		var wire uint64
 		for shift := uint(0); ; shift += 7 {
@@ -263,10 +600,8 @@ func skipConnectResponse(dAtA []byte) (n int, err error) {
 					break
 				}
 			}
-			return iNdEx, nil
 		case 1:
 			iNdEx += 8
-			return iNdEx, nil
 		case 2:
 			var length int
 			for shift := uint(0); ; shift += 7 {
@@ -283,68 +618,34 @@ func skipConnectResponse(dAtA []byte) (n int, err error) {
 					break
 				}
 			}
-			iNdEx += length
 			if length < 0 {
 				return 0, ErrInvalidLengthConnectResponse
 			}
-			return iNdEx, nil
+			iNdEx += length
 		case 3:
-			for {
-				var innerWire uint64
-				var start int = iNdEx
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return 0, ErrIntOverflowConnectResponse
-					}
-					if iNdEx >= l {
-						return 0, io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					innerWire |= (uint64(b) & 0x7F) << shift
-					if b < 0x80 {
-						break
-					}
-				}
-				innerWireType := int(innerWire & 0x7)
-				if innerWireType == 4 {
-					break
-				}
-				next, err := skipConnectResponse(dAtA[start:])
-				if err != nil {
-					return 0, err
-				}
-				iNdEx = start + next
-			}
-			return iNdEx, nil
+			depth++
 		case 4:
-			return iNdEx, nil
+			if depth == 0 {
+				return 0, ErrUnexpectedEndOfGroupConnectResponse
+			}
+			depth--
 		case 5:
 			iNdEx += 4
-			return iNdEx, nil
 		default:
 			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
 		}
+		if iNdEx < 0 {
+			return 0, ErrInvalidLengthConnectResponse
+		}
+		if depth == 0 {
+			return iNdEx, nil
+		}
 	}
-	panic("unreachable")
+	return 0, io.ErrUnexpectedEOF
 }
 
 var (
-	ErrInvalidLengthConnectResponse = fmt.Errorf("proto: negative length found during unmarshaling")
-	ErrIntOverflowConnectResponse   = fmt.Errorf("proto: integer overflow")
+	ErrInvalidLengthConnectResponse        = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowConnectResponse          = fmt.Errorf("proto: integer overflow")
+	ErrUnexpectedEndOfGroupConnectResponse = fmt.Errorf("proto: unexpected end of group")
 )
-
-func init() { proto.RegisterFile("connect_response.proto", fileDescriptorConnectResponse) }
-
-var fileDescriptorConnectResponse = []byte{
-	// 135 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x12, 0x4b, 0xce, 0xcf, 0xcb,
-	0x4b, 0x4d, 0x2e, 0x89, 0x2f, 0x4a, 0x2d, 0x2e, 0xc8, 0xcf, 0x2b, 0x4e, 0xd5, 0x2b, 0x28, 0xca,
-	0x2f, 0xc9, 0x17, 0x62, 0xcb, 0x4b, 0x2d, 0xc9, 0x2d, 0x4e, 0x57, 0xf2, 0xe5, 0xe2, 0x77, 0x86,
-	0xa8, 0x08, 0x82, 0x2a, 0x10, 0x92, 0xe3, 0xe2, 0x72, 0xce, 0xc9, 0x4c, 0xcd, 0x2b, 0x09, 0xce,
-	0xc9, 0x2f, 0x91, 0x60, 0x54, 0x60, 0xd4, 0x60, 0x0d, 0x42, 0x12, 0x11, 0xe2, 0xe1, 0x62, 0x8c,
-	0x90, 0x60, 0x52, 0x60, 0xd4, 0x60, 0x0c, 0x62, 0x8c, 0x00, 0xf1, 0x22, 0x25, 0x98, 0x21, 0xbc,
-	0x48, 0x27, 0x81, 0x13, 0x8f, 0xe4, 0x18, 0x2f, 0x3c, 0x92, 0x63, 0x7c, 0xf0, 0x48, 0x8e, 0x71,
-	0xc6, 0x63, 0x39, 0x86, 0x24, 0x36, 0xb0, 0x7d, 0xc6, 0x80, 0x00, 0x00, 0x00, 0xff, 0xff, 0x03,
-	0x94, 0x49, 0x55, 0x89, 0x00, 0x00, 0x00,
-}
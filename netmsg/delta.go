@@ -0,0 +1,167 @@
+package netmsg
+
+// Bits of PlayerState.Changed, one for each field that can change.
+const (
+	FieldX uint32 = 1 << iota
+	FieldY
+	FieldVX
+	FieldVY
+	// IsKeyLeftPressed, IsKeyRightPressed and IsKeyJumpPressed
+	FieldKeys
+	FieldConnectionInterrupted
+
+	FieldAll = FieldX | FieldY | FieldVX | FieldVY | FieldKeys | FieldConnectionInterrupted
+)
+
+// snapshotHistorySize is how many snapshots are kept to be used as
+// baselines, about a second's worth at 60 ticks per second.
+const snapshotHistorySize = 64
+
+// Players is the full state of every player in a snapshot, by client slot.
+type Players map[int32]*PlayerState
+
+type snapshotHistoryEntry struct {
+	tick    uint64
+	players Players
+}
+
+// SnapshotHistory remembers the players in recent snapshots, so they can be
+// used as the baseline for delta snapshots.
+type SnapshotHistory struct {
+	entries [snapshotHistorySize]snapshotHistoryEntry
+}
+
+// Add remembers the players at tick, replacing the oldest snapshot.
+func (h *SnapshotHistory) Add(tick uint64, players Players) {
+	h.entries[tick%snapshotHistorySize] = snapshotHistoryEntry{
+		tick:    tick,
+		players: players,
+	}
+}
+
+// Get returns the players at tick, if that snapshot is still remembered.
+// Tick 0 is never remembered, as a BaselineTick of 0 means there is no
+// baseline.
+func (h *SnapshotHistory) Get(tick uint64) (Players, bool) {
+	entry := &h.entries[tick%snapshotHistorySize]
+	if tick == 0 || entry.tick != tick || entry.players == nil {
+		return nil, false
+	}
+	return entry.players, true
+}
+
+// DiffPlayerState returns the fields of current that differ from base, or
+// nil if nothing changed. If base is nil every field is returned.
+func DiffPlayerState(base, current *PlayerState) *PlayerState {
+	if base == nil {
+		delta := *current
+		delta.Changed = FieldAll
+		return &delta
+	}
+	delta := &PlayerState{
+		ClientSlot: current.ClientSlot,
+	}
+	if current.X != base.X {
+		delta.X = current.X
+		delta.Changed |= FieldX
+	}
+	if current.Y != base.Y {
+		delta.Y = current.Y
+		delta.Changed |= FieldY
+	}
+	if current.VX != base.VX {
+		delta.VX = current.VX
+		delta.Changed |= FieldVX
+	}
+	if current.VY != base.VY {
+		delta.VY = current.VY
+		delta.Changed |= FieldVY
+	}
+	if current.IsKeyLeftPressed != base.IsKeyLeftPressed ||
+		current.IsKeyRightPressed != base.IsKeyRightPressed ||
+		current.IsKeyJumpPressed != base.IsKeyJumpPressed {
+		delta.IsKeyLeftPressed = current.IsKeyLeftPressed
+		delta.IsKeyRightPressed = current.IsKeyRightPressed
+		delta.IsKeyJumpPressed = current.IsKeyJumpPressed
+		delta.Changed |= FieldKeys
+	}
+	if current.ConnectionInterrupted != base.ConnectionInterrupted {
+		delta.ConnectionInterrupted = current.ConnectionInterrupted
+		delta.Changed |= FieldConnectionInterrupted
+	}
+	if delta.Changed == 0 {
+		return nil
+	}
+	return delta
+}
+
+// ApplyPlayerStateDelta returns a copy of base with the changed fields of
+// delta applied. base can be nil if delta has every field.
+func ApplyPlayerStateDelta(base, delta *PlayerState) *PlayerState {
+	result := &PlayerState{
+		ClientSlot: delta.ClientSlot,
+	}
+	if base != nil {
+		*result = *base
+	}
+	if delta.Changed&FieldX != 0 {
+		result.X = delta.X
+	}
+	if delta.Changed&FieldY != 0 {
+		result.Y = delta.Y
+	}
+	if delta.Changed&FieldVX != 0 {
+		result.VX = delta.VX
+	}
+	if delta.Changed&FieldVY != 0 {
+		result.VY = delta.VY
+	}
+	if delta.Changed&FieldKeys != 0 {
+		result.IsKeyLeftPressed = delta.IsKeyLeftPressed
+		result.IsKeyRightPressed = delta.IsKeyRightPressed
+		result.IsKeyJumpPressed = delta.IsKeyJumpPressed
+	}
+	if delta.Changed&FieldConnectionInterrupted != 0 {
+		result.ConnectionInterrupted = delta.ConnectionInterrupted
+	}
+	result.Changed = FieldAll
+	return result
+}
+
+// NewDeltaSnapshot creates a snapshot of current at tick, with only what
+// changed since baseline. If baseline is nil, baselineTick should be 0 and
+// the snapshot has every player in full.
+func NewDeltaSnapshot(tick uint64, baselineTick uint64, baseline, current Players) *WorldSnapshot {
+	snapshot := &WorldSnapshot{
+		Tick:         tick,
+		BaselineTick: baselineTick,
+	}
+	for clientSlot, state := range current {
+		if delta := DiffPlayerState(baseline[clientSlot], state); delta != nil {
+			snapshot.Players = append(snapshot.Players, delta)
+		}
+	}
+	for clientSlot := range baseline {
+		if _, ok := current[clientSlot]; !ok {
+			snapshot.RemovedSlots = append(snapshot.RemovedSlots, clientSlot)
+		}
+	}
+	return snapshot
+}
+
+// ApplyDeltaSnapshot returns the full state of every player in snapshot,
+// by applying it to the players in its baseline. baseline should be nil if
+// the snapshot has no baseline.
+func ApplyDeltaSnapshot(baseline Players, snapshot *WorldSnapshot) Players {
+	players := make(Players, len(baseline)+len(snapshot.Players))
+	for clientSlot, state := range baseline {
+		players[clientSlot] = state
+	}
+	for _, clientSlot := range snapshot.RemovedSlots {
+		delete(players, clientSlot)
+	}
+	for _, delta := range snapshot.Players {
+		players[delta.ClientSlot] = ApplyPlayerStateDelta(players[delta.ClientSlot], delta)
+	}
+	return players
+}
@@ -0,0 +1,132 @@
+package netmsg
+
+import (
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+)
+
+func TestApplyDeltaSnapshot(t *testing.T) {
+	baseline := Players{
+		0: {ClientSlot: 0, X: 10, Y: 20, VX: 1},
+		1: {ClientSlot: 1, X: 30, Y: 40, IsKeyJumpPressed: true},
+		2: {ClientSlot: 2, X: 50, Y: 60},
+		3: {ClientSlot: 3, X: 70, Y: 80},
+	}
+	current := Players{
+		// Moved while holding right
+		0: {ClientSlot: 0, X: 11, Y: 20, VX: 1, IsKeyRightPressed: true},
+		// Let go of jump, so the only change is a field going back to zero
+		1: {ClientSlot: 1, X: 30, Y: 40},
+		// Slot 2 left and slot 3 stood still
+		3: {ClientSlot: 3, X: 70, Y: 80},
+		4: {ClientSlot: 4, X: 90, Y: 100, ConnectionInterrupted: true},
+	}
+
+	snapshot := NewDeltaSnapshot(2, 1, baseline, current)
+
+	// Only what changed is sent, and only the fields that changed
+	sent := make(map[int32]*PlayerState)
+	for _, delta := range snapshot.Players {
+		sent[delta.ClientSlot] = delta
+	}
+	if len(sent) != 3 {
+		t.Fatalf("got %d players in the snapshot, want 3", len(sent))
+	}
+	if got, want := sent[0].Changed, FieldX|FieldKeys; got != want {
+		t.Fatalf("slot 0: got changed fields %b, want %b", got, want)
+	}
+	if got, want := sent[1].Changed, FieldKeys; got != want {
+		t.Fatalf("slot 1: got changed fields %b, want %b", got, want)
+	}
+	if _, ok := sent[3]; ok {
+		t.Fatal("slot 3 was sent without changing")
+	}
+	if got := sent[4].Changed; got != FieldAll {
+		t.Fatalf("slot 4: got changed fields %b, want all of them", got)
+	}
+	if len(snapshot.RemovedSlots) != 1 || snapshot.RemovedSlots[0] != 2 {
+		t.Fatalf("got removed slots %v, want [2]", snapshot.RemovedSlots)
+	}
+
+	// It arrives the same as it was sent
+	packetData, err := Encode(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, msg, err := Decode(packetData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	players := ApplyDeltaSnapshot(baseline, msg.(*WorldSnapshot))
+	if len(players) != len(current) {
+		t.Fatalf("got %d players, want %d", len(players), len(current))
+	}
+	for clientSlot, want := range current {
+		got, ok := players[clientSlot]
+		if !ok {
+			t.Fatalf("slot %d: missing", clientSlot)
+		}
+		// Changed only says what the message carried, so ignore it
+		got, want = withoutChanged(got), withoutChanged(want)
+		if !proto.Equal(got, want) {
+			t.Fatalf("slot %d: got %v, want %v", clientSlot, got, want)
+		}
+	}
+
+	// The baseline is left as it was, as it may be used again
+	if baseline[0].X != 10 || len(baseline) != 4 {
+		t.Fatal("applying the snapshot changed its baseline")
+	}
+}
+
+func withoutChanged(state *PlayerState) *PlayerState {
+	stripped := *state
+	stripped.Changed = 0
+	return &stripped
+}
+
+func TestApplyDeltaSnapshotWithoutBaseline(t *testing.T) {
+	current := Players{
+		0: {ClientSlot: 0, X: 10},
+		1: {ClientSlot: 1, Y: 20},
+	}
+	players := ApplyDeltaSnapshot(nil, NewDeltaSnapshot(1, 0, nil, current))
+	if len(players) != 2 || players[0].X != 10 || players[1].Y != 20 {
+		t.Fatalf("got %v, want %v", players, current)
+	}
+}
+
+func TestSnapshotHistory(t *testing.T) {
+	var history SnapshotHistory
+	if _, ok := history.Get(1); ok {
+		t.Fatal("got a snapshot from an empty history")
+	}
+	history.Add(0, Players{})
+	if _, ok := history.Get(0); ok {
+		t.Fatal("got a snapshot for tick 0, which means no baseline")
+	}
+
+	for tick := uint64(1); tick <= snapshotHistorySize+1; tick++ {
+		history.Add(tick, Players{0: {X: int32(tick)}})
+	}
+
+	// Tick 1 shares a slot with the newest tick, so it's gone
+	if _, ok := history.Get(1); ok {
+		t.Fatal("got tick 1 after it was replaced")
+	}
+	for _, tick := range []uint64{2, snapshotHistorySize, snapshotHistorySize + 1} {
+		players, ok := history.Get(tick)
+		if !ok {
+			t.Fatalf("tick %d: not found", tick)
+		}
+		if players[0].X != int32(tick) {
+			t.Fatalf("tick %d: got the snapshot from tick %d", tick, players[0].X)
+		}
+	}
+
+	// Ticks that haven't happened yet aren't mistaken for old ones
+	if _, ok := history.Get(snapshotHistorySize + 2); ok {
+		t.Fatal("got a snapshot from the future")
+	}
+}
@@ -1,22 +1,15 @@
 // Code generated by protoc-gen-gogo. DO NOT EDIT.
 // source: disconnect.proto
 
-/*
-Package netmsg is a generated protocol buffer package.
-
-It is generated from these files:
-	disconnect.proto
-
-It has these top-level messages:
-	DisconnectPlayer
-*/
 package netmsg
 
-import proto "github.com/golang/protobuf/proto"
-import fmt "fmt"
-import math "math"
-
-import io "io"
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	io "io"
+	math "math"
+	math_bits "math/bits"
+)
 
 // Reference imports to suppress errors if they are not otherwise used.
 var _ = proto.Marshal
@@ -27,16 +20,47 @@ var _ = math.Inf
 // is compatible with the proto package it is being compiled against.
 // A compilation error at this line likely means your copy of the
 // proto package needs to be updated.
-const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
 type DisconnectPlayer struct {
-	ClientSlot int32 `protobuf:"varint,1,opt,name=ClientSlot,proto3" json:"ClientSlot,omitempty"`
+	ClientSlot           int32    `protobuf:"varint,1,opt,name=ClientSlot,proto3" json:"ClientSlot,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DisconnectPlayer) Reset()         { *m = DisconnectPlayer{} }
+func (m *DisconnectPlayer) String() string { return proto.CompactTextString(m) }
+func (*DisconnectPlayer) ProtoMessage()    {}
+func (*DisconnectPlayer) Descriptor() ([]byte, []int) {
+	return fileDescriptor_32e55f87b4175bd6, []int{0}
+}
+func (m *DisconnectPlayer) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *DisconnectPlayer) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_DisconnectPlayer.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *DisconnectPlayer) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DisconnectPlayer.Merge(m, src)
+}
+func (m *DisconnectPlayer) XXX_Size() int {
+	return m.Size()
+}
+func (m *DisconnectPlayer) XXX_DiscardUnknown() {
+	xxx_messageInfo_DisconnectPlayer.DiscardUnknown(m)
 }
 
-func (m *DisconnectPlayer) Reset()                    { *m = DisconnectPlayer{} }
-func (m *DisconnectPlayer) String() string            { return proto.CompactTextString(m) }
-func (*DisconnectPlayer) ProtoMessage()               {}
-func (*DisconnectPlayer) Descriptor() ([]byte, []int) { return fileDescriptorDisconnect, []int{0} }
+var xxx_messageInfo_DisconnectPlayer proto.InternalMessageInfo
 
 func (m *DisconnectPlayer) GetClientSlot() int32 {
 	if m != nil {
@@ -48,10 +72,24 @@ func (m *DisconnectPlayer) GetClientSlot() int32 {
 func init() {
 	proto.RegisterType((*DisconnectPlayer)(nil), "netmsg.DisconnectPlayer")
 }
+
+func init() { proto.RegisterFile("disconnect.proto", fileDescriptor_32e55f87b4175bd6) }
+
+var fileDescriptor_32e55f87b4175bd6 = []byte{
+	// 107 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x12, 0x48, 0xc9, 0x2c, 0x4e,
+	0xce, 0xcf, 0xcb, 0x4b, 0x4d, 0x2e, 0xd1, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0xcb, 0x4b,
+	0x2d, 0xc9, 0x2d, 0x4e, 0x57, 0x32, 0xe2, 0x12, 0x70, 0x81, 0xcb, 0x05, 0xe4, 0x24, 0x56, 0xa6,
+	0x16, 0x09, 0xc9, 0x71, 0x71, 0x39, 0xe7, 0x64, 0xa6, 0xe6, 0x95, 0x04, 0xe7, 0xe4, 0x97, 0x48,
+	0x30, 0x2a, 0x30, 0x6a, 0xb0, 0x06, 0x21, 0x89, 0x38, 0x09, 0x9c, 0x78, 0x24, 0xc7, 0x78, 0xe1,
+	0x91, 0x1c, 0xe3, 0x83, 0x47, 0x72, 0x8c, 0x33, 0x1e, 0xcb, 0x31, 0x24, 0xb1, 0x81, 0x0d, 0x35,
+	0x06, 0x0c, 0x00, 0xac, 0xa1, 0xcd, 0x84, 0x68, 0x00, 0x00, 0x00,
+}
+
 func (m *DisconnectPlayer) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
-	n, err := m.MarshalTo(dAtA)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
 	if err != nil {
 		return nil, err
 	}
@@ -59,45 +97,55 @@ func (m *DisconnectPlayer) Marshal() (dAtA []byte, err error) {
 }
 
 func (m *DisconnectPlayer) MarshalTo(dAtA []byte) (int, error) {
-	var i int
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *DisconnectPlayer) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
 	_ = i
 	var l int
 	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
 	if m.ClientSlot != 0 {
-		dAtA[i] = 0x8
-		i++
 		i = encodeVarintDisconnect(dAtA, i, uint64(m.ClientSlot))
+		i--
+		dAtA[i] = 0x8
 	}
-	return i, nil
+	return len(dAtA) - i, nil
 }
 
 func encodeVarintDisconnect(dAtA []byte, offset int, v uint64) int {
+	offset -= sovDisconnect(v)
+	base := offset
 	for v >= 1<<7 {
 		dAtA[offset] = uint8(v&0x7f | 0x80)
 		v >>= 7
 		offset++
 	}
 	dAtA[offset] = uint8(v)
-	return offset + 1
+	return base
 }
 func (m *DisconnectPlayer) Size() (n int) {
+	if m == nil {
+		return 0
+	}
 	var l int
 	_ = l
 	if m.ClientSlot != 0 {
 		n += 1 + sovDisconnect(uint64(m.ClientSlot))
 	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
 	return n
 }
 
 func sovDisconnect(x uint64) (n int) {
-	for {
-		n++
-		x >>= 7
-		if x == 0 {
-			break
-		}
-	}
-	return n
+	return (math_bits.Len64(x|1) + 6) / 7
 }
 func sozDisconnect(x uint64) (n int) {
 	return sovDisconnect(uint64((x << 1) ^ uint64((int64(x) >> 63))))
@@ -117,7 +165,7 @@ func (m *DisconnectPlayer) Unmarshal(dAtA []byte) error {
 			}
 			b := dAtA[iNdEx]
 			iNdEx++
-			wire |= (uint64(b) & 0x7F) << shift
+			wire |= uint64(b&0x7F) << shift
 			if b < 0x80 {
 				break
 			}
@@ -145,7 +193,7 @@ func (m *DisconnectPlayer) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ClientSlot |= (int32(b) & 0x7F) << shift
+				m.ClientSlot |= int32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -156,12 +204,13 @@ func (m *DisconnectPlayer) Unmarshal(dAtA []byte) error {
 			if err != nil {
 				return err
 			}
-			if skippy < 0 {
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
 				return ErrInvalidLengthDisconnect
 			}
 			if (iNdEx + skippy) > l {
 				return io.ErrUnexpectedEOF
 			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
 			iNdEx += skippy
 		}
 	}
@@ -174,6 +223,7 @@ func (m *DisconnectPlayer) Unmarshal(dAtA []byte) error {
 func skipDisconnect(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
+	depth := 0
 	for iNdEx < l {
 		var wire uint64
 		for shift := uint(0); ; shift += 7 {
@@ -205,10 +255,8 @@ func skipDisconnect(dAtA []byte) (n int, err error) {
 					break
 				}
 			}
-			return iNdEx, nil
 		case 1:
 			iNdEx += 8
-			return iNdEx, nil
 		case 2:
 			var length int
 			for shift := uint(0); ; shift += 7 {
@@ -225,66 +273,34 @@ func skipDisconnect(dAtA []byte) (n int, err error) {
 					break
 				}
 			}
-			iNdEx += length
 			if length < 0 {
 				return 0, ErrInvalidLengthDisconnect
 			}
-			return iNdEx, nil
+			iNdEx += length
 		case 3:
-			for {
-				var innerWire uint64
-				var start int = iNdEx
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return 0, ErrIntOverflowDisconnect
-					}
-					if iNdEx >= l {
-						return 0, io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					innerWire |= (uint64(b) & 0x7F) << shift
-					if b < 0x80 {
-						break
-					}
-				}
-				innerWireType := int(innerWire & 0x7)
-				if innerWireType == 4 {
-					break
-				}
-				next, err := skipDisconnect(dAtA[start:])
-				if err != nil {
-					return 0, err
-				}
-				iNdEx = start + next
-			}
-			return iNdEx, nil
+			depth++
 		case 4:
-			return iNdEx, nil
+			if depth == 0 {
+				return 0, ErrUnexpectedEndOfGroupDisconnect
+			}
+			depth--
 		case 5:
 			iNdEx += 4
-			return iNdEx, nil
 		default:
 			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
 		}
+		if iNdEx < 0 {
+			return 0, ErrInvalidLengthDisconnect
+		}
+		if depth == 0 {
+			return iNdEx, nil
+		}
 	}
-	panic("unreachable")
+	return 0, io.ErrUnexpectedEOF
 }
 
 var (
-	ErrInvalidLengthDisconnect = fmt.Errorf("proto: negative length found during unmarshaling")
-	ErrIntOverflowDisconnect   = fmt.Errorf("proto: integer overflow")
+	ErrInvalidLengthDisconnect        = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowDisconnect          = fmt.Errorf("proto: integer overflow")
+	ErrUnexpectedEndOfGroupDisconnect = fmt.Errorf("proto: unexpected end of group")
 )
-
-func init() { proto.RegisterFile("disconnect.proto", fileDescriptorDisconnect) }
-
-var fileDescriptorDisconnect = []byte{
-	// 110 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x12, 0x48, 0xc9, 0x2c, 0x4e,
-	0xce, 0xcf, 0xcb, 0x4b, 0x4d, 0x2e, 0xd1, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0xcb, 0x4b,
-	0x2d, 0xc9, 0x2d, 0x4e, 0x57, 0x32, 0xe2, 0x12, 0x70, 0x81, 0xcb, 0x05, 0xe4, 0x24, 0x56, 0xa6,
-	0x16, 0x09, 0xc9, 0x71, 0x71, 0x39, 0xe7, 0x64, 0xa6, 0xe6, 0x95, 0x04, 0xe7, 0xe4, 0x97, 0x48,
-	0x30, 0x2a, 0x30, 0x6a, 0xb0, 0x06, 0x21, 0x89, 0x38, 0x09, 0x9c, 0x78, 0x24, 0xc7, 0x78, 0xe1,
-	0x91, 0x1c, 0xe3, 0x83, 0x47, 0x72, 0x8c, 0x33, 0x1e, 0xcb, 0x31, 0x24, 0xb1, 0x81, 0x0d, 0x35,
-	0x06, 0x04, 0x00, 0x00, 0xff, 0xff, 0xac, 0xa1, 0xcd, 0x84, 0x68, 0x00, 0x00, 0x00,
-}
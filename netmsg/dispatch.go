@@ -0,0 +1,75 @@
+package netmsg
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Dispatcher decodes packets and calls the handler registered for their
+// kind.
+//
+// Handlers are functions whose last parameter is a pointer to a registered
+// message type, such as func(*ConnectResponse). Any parameters before it
+// are filled from the extra arguments given to Dispatch, so a server can
+// use func(*gameserver.Client, *UpdatePlayer) to know who sent it.
+type Dispatcher struct {
+	handlers map[Kind]reflect.Value
+}
+
+// UnhandledError is returned by Dispatch when a packet decodes fine but
+// there is no handler for its kind, such as a client sending a message
+// only the server should send.
+type UnhandledError struct {
+	Kind Kind
+}
+
+func (err *UnhandledError) Error() string {
+	return fmt.Sprintf("Unhandled message kind: %s", err.Kind)
+}
+
+// Handle registers handler for the message type of its last parameter,
+// replacing any handler already registered for it. It panics if handler
+// isn't a function taking a registered message type.
+func (d *Dispatcher) Handle(handler interface{}) {
+	fn := reflect.ValueOf(handler)
+	t := fn.Type()
+	if t.Kind() != reflect.Func || t.NumIn() == 0 || t.NumOut() != 0 {
+		panic(fmt.Sprintf("netmsg: handler must be a function taking a message and returning nothing, not %s", t))
+	}
+	kind, ok := typeToKind[t.In(t.NumIn()-1)]
+	if !ok {
+		panic(fmt.Sprintf("netmsg: handler %s takes %s, which has not been registered", t, t.In(t.NumIn()-1)))
+	}
+	if d.handlers == nil {
+		d.handlers = make(map[Kind]reflect.Value)
+	}
+	d.handlers[kind] = fn
+}
+
+// Dispatch decodes packetData and calls the handler for its kind with args
+// followed by the message.
+func (d *Dispatcher) Dispatch(packetData []byte, args ...interface{}) error {
+	kind, msg, err := Decode(packetData)
+	if err != nil {
+		return err
+	}
+	fn, ok := d.handlers[kind]
+	if !ok {
+		return &UnhandledError{Kind: kind}
+	}
+	t := fn.Type()
+	if len(args) != t.NumIn()-1 {
+		return fmt.Errorf("%s: handler takes %d arguments before the message, got %d", kind, t.NumIn()-1, len(args))
+	}
+	in := make([]reflect.Value, 0, len(args)+1)
+	for i, arg := range args {
+		v := reflect.ValueOf(arg)
+		if !v.IsValid() || !v.Type().AssignableTo(t.In(i)) {
+			return fmt.Errorf("%s: handler argument %d must be %s, got %T", kind, i, t.In(i), arg)
+		}
+		in = append(in, v)
+	}
+	in = append(in, reflect.ValueOf(msg))
+	fn.Call(in)
+	return nil
+}
@@ -0,0 +1,57 @@
+package netmsg
+
+import (
+	"testing"
+)
+
+func TestDispatch(t *testing.T) {
+	var (
+		d       Dispatcher
+		gotFrom string
+		got     *ChatSend
+	)
+	d.Handle(func(from string, msg *ChatSend) {
+		gotFrom, got = from, msg
+	})
+
+	packetData, err := Encode(&ChatSend{Text: "Hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Dispatch(packetData, "Bob"); err != nil {
+		t.Fatal(err)
+	}
+	if gotFrom != "Bob" || got == nil || got.Text != "Hi" {
+		t.Fatalf("handler got %q and %v", gotFrom, got)
+	}
+
+	// Arguments that don't match the handler are an error, not a panic
+	for _, args := range [][]interface{}{nil, {1}, {"Bob", "Alice"}} {
+		if err := d.Dispatch(packetData, args...); err == nil {
+			t.Fatalf("dispatched with %v", args)
+		}
+	}
+}
+
+func TestDispatchUnhandled(t *testing.T) {
+	var d Dispatcher
+	d.Handle(func(*ChatSend) {
+		t.Fatal("called the wrong handler")
+	})
+
+	// A server doesn't handle messages only it sends
+	packetData, err := Encode(&WorldSnapshot{Tick: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = d.Dispatch(packetData)
+	unhandled, ok := err.(*UnhandledError)
+	if !ok || unhandled.Kind != MsgWorldSnapshot {
+		t.Fatalf("got %v, want an UnhandledError for %s", err, MsgWorldSnapshot)
+	}
+
+	// Packets that don't decode fail before looking for a handler
+	if _, ok := d.Dispatch([]byte{255}).(*UnknownKindError); !ok {
+		t.Fatal("dispatched a packet of an unknown kind")
+	}
+}
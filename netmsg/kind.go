@@ -3,17 +3,50 @@ package netmsg
 type Kind byte
 
 const (
-	MsgUnknown          Kind = 0 + iota
-	MsgConnectResponse       = 1
-	MsgUpdatePlayer          = 2
-	MsgDisconnectPlayer      = 3
+	MsgUnknown          Kind = 0
+	MsgConnectResponse  Kind = 1
+	MsgUpdatePlayer     Kind = 2
+	MsgDisconnectPlayer Kind = 3
+	MsgServerShutdown   Kind = 4
+	MsgWorldSnapshot    Kind = 5
+	MsgHello            Kind = 6
+	MsgReject           Kind = 7
+	MsgPlayerJoined     Kind = 8
+	MsgChatSend         Kind = 9
+	MsgChatMessage      Kind = 10
+	MsgPing             Kind = 11
+	MsgPong             Kind = 12
 )
 
+func init() {
+	Register(MsgConnectResponse, &ConnectResponse{})
+	Register(MsgUpdatePlayer, &UpdatePlayer{})
+	Register(MsgDisconnectPlayer, &DisconnectPlayer{})
+	Register(MsgServerShutdown, &ServerShutdown{})
+	Register(MsgWorldSnapshot, &WorldSnapshot{})
+	Register(MsgHello, &Hello{})
+	Register(MsgReject, &Reject{})
+	Register(MsgPlayerJoined, &PlayerJoined{})
+	Register(MsgChatSend, &ChatSend{})
+	Register(MsgChatMessage, &ChatMessage{})
+	Register(MsgPing, &Ping{})
+	Register(MsgPong, &Pong{})
+}
+
 var kindToString = []string{
 	MsgUnknown:          "MsgUnknown",
 	MsgConnectResponse:  "MsgConnectResponse",
 	MsgUpdatePlayer:     "MsgUpdatePlayer",
 	MsgDisconnectPlayer: "MsgDisconnectPlayer",
+	MsgServerShutdown:   "MsgServerShutdown",
+	MsgWorldSnapshot:    "MsgWorldSnapshot",
+	MsgHello:            "MsgHello",
+	MsgReject:           "MsgReject",
+	MsgPlayerJoined:     "MsgPlayerJoined",
+	MsgChatSend:         "MsgChatSend",
+	MsgChatMessage:      "MsgChatMessage",
+	MsgPing:             "MsgPing",
+	MsgPong:             "MsgPong",
 }
 
 func (kind Kind) String() string {
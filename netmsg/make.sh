@@ -3,3 +3,11 @@
 protoc --gofast_out=. connect_response.proto
 protoc --gofast_out=. update_player.proto
 protoc --gofast_out=. disconnect.proto
+protoc --gofast_out=. server_shutdown.proto
+protoc --gofast_out=. world_snapshot.proto
+protoc --gofast_out=. hello.proto
+protoc --gofast_out=. reject.proto
+protoc --gofast_out=. player_joined.proto
+protoc --gofast_out=. chat_send.proto
+protoc --gofast_out=. chat_message.proto
+protoc --gofast_out=. ping.proto
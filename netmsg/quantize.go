@@ -0,0 +1,42 @@
+package netmsg
+
+import "math"
+
+// DefaultPositionPrecision is the number of fractional bits positions and
+// velocities are sent with by default, which is 1/16th of a pixel.
+const DefaultPositionPrecision = 4
+
+// MaxPositionPrecision leaves enough integer bits for positions of
+// up to +/-32768 pixels.
+const MaxPositionPrecision = 16
+
+// Quantizer converts positions and velocities to and from fixed-point
+// integers with Precision fractional bits. These are sent as sint32, so
+// a position in a 1024x512 world at the default precision takes 2 or 3
+// bytes instead of the 8 bytes of a double.
+type Quantizer struct {
+	Precision uint32
+}
+
+func (q Quantizer) scale() float64 {
+	return float64(uint64(1) << q.Precision)
+}
+
+// Encode rounds v to the nearest step of the precision. Values too large
+// to send are clamped to the largest that can be.
+func (q Quantizer) Encode(v float64) int32 {
+	f := math.Floor(v*q.scale() + 0.5)
+	switch {
+	case f >= math.MaxInt32:
+		return math.MaxInt32
+	case f <= math.MinInt32:
+		return math.MinInt32
+	case math.IsNaN(f):
+		return 0
+	}
+	return int32(f)
+}
+
+func (q Quantizer) Decode(v int32) float64 {
+	return float64(v) / q.scale()
+}
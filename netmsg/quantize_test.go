@@ -0,0 +1,114 @@
+package netmsg
+
+import (
+	"math"
+	"testing"
+
+	proto "github.com/gogo/protobuf/proto"
+)
+
+func TestQuantizer(t *testing.T) {
+	tests := []struct {
+		precision uint32
+		v         float64
+		want      int32
+	}{
+		{0, 0, 0},
+		{0, 1.4, 1},
+		{0, 1.5, 2},
+		{0, -1.5, -1},
+		{DefaultPositionPrecision, 37.3, 597},
+		{DefaultPositionPrecision, -600.03125, -9600},
+		{MaxPositionPrecision, 32767.99999, math.MaxInt32},
+		{MaxPositionPrecision, -32768, math.MinInt32},
+		// Out of range values are clamped
+		{MaxPositionPrecision, 40000, math.MaxInt32},
+		{MaxPositionPrecision, -40000, math.MinInt32},
+		{0, math.Inf(1), math.MaxInt32},
+		{0, math.Inf(-1), math.MinInt32},
+		{0, math.NaN(), 0},
+	}
+	for _, test := range tests {
+		q := Quantizer{Precision: test.precision}
+		got := q.Encode(test.v)
+		if got != test.want {
+			t.Errorf("precision %d: Encode(%v) = %d, want %d", test.precision, test.v, got, test.want)
+		}
+		if got == math.MaxInt32 || got == math.MinInt32 || math.IsNaN(test.v) {
+			continue
+		}
+		// Within half a step of where it started
+		if diff := math.Abs(q.Decode(got) - test.v); diff > 0.5/q.scale() {
+			t.Errorf("precision %d: %v came back as %v", test.precision, test.v, q.Decode(got))
+		}
+	}
+}
+
+// benchmarkPlayers are moving players scattered across a 1024x512 level,
+// so every position and velocity field is set in a full snapshot.
+func benchmarkPlayers(q Quantizer) Players {
+	players := make(Players)
+	for i := int32(0); i < 16; i++ {
+		players[i] = &PlayerState{
+			ClientSlot:        i,
+			X:                 q.Encode(37.3 + float64(i)*61.7),
+			Y:                 q.Encode(448 - float64(i)*23.9),
+			VX:                q.Encode(180),
+			VY:                q.Encode(-600 + float64(i)*71.3),
+			IsKeyRightPressed: true,
+			Changed:           FieldAll,
+		}
+	}
+	return players
+}
+
+// marshalDoubles encodes a PlayerState the way it was sent before
+// quantization, with each position and velocity as a double.
+func marshalDoubles(q Quantizer, state *PlayerState) []byte {
+	buf := proto.NewBuffer(nil)
+	buf.EncodeVarint(1<<3 | proto.WireVarint)
+	buf.EncodeVarint(uint64(state.ClientSlot))
+	for i, v := range []int32{state.X, state.Y, state.VX, state.VY} {
+		buf.EncodeVarint(uint64(i+2)<<3 | proto.WireFixed64)
+		buf.EncodeFixed64(math.Float64bits(q.Decode(v)))
+	}
+	buf.EncodeVarint(7<<3 | proto.WireVarint)
+	buf.EncodeVarint(1)
+	buf.EncodeVarint(10<<3 | proto.WireVarint)
+	buf.EncodeVarint(uint64(state.Changed))
+	return buf.Bytes()
+}
+
+func BenchmarkPlayerStateDouble(b *testing.B) {
+	q := Quantizer{Precision: DefaultPositionPrecision}
+	players := benchmarkPlayers(q)
+	b.ReportAllocs()
+	b.ResetTimer()
+	size := 0
+	for i := 0; i < b.N; i++ {
+		size = 0
+		for _, state := range players {
+			size += len(marshalDoubles(q, state))
+		}
+	}
+	b.ReportMetric(float64(size)/float64(len(players)), "bytes/player")
+}
+
+func BenchmarkPlayerStateQuantized(b *testing.B) {
+	q := Quantizer{Precision: DefaultPositionPrecision}
+	players := benchmarkPlayers(q)
+	b.ReportAllocs()
+	b.ResetTimer()
+	size := 0
+	for i := 0; i < b.N; i++ {
+		size = 0
+		for _, state := range players {
+			data, err := proto.Marshal(state)
+			if err != nil {
+				b.Fatal(err)
+			}
+			size += len(data)
+		}
+	}
+	b.ReportMetric(float64(size)/float64(len(players)), "bytes/player")
+}
@@ -0,0 +1,44 @@
+package netmsg
+
+import "math"
+
+// maxOtherMessageSize is the most any message but WorldSnapshot takes
+// encoded. Chat, names and reasons are short, so they all fit.
+const maxOtherMessageSize = 512
+
+// MaxMessageSize is the largest message a server with maxClients players
+// sends, including the kind byte. It is a full WorldSnapshot with every
+// player in it, unless there are so few players that another message is
+// larger.
+func MaxMessageSize(maxClients int32) int64 {
+	if maxClients < 0 {
+		maxClients = 0
+	}
+	// Every field set to a value that takes the most bytes to encode
+	player := &PlayerState{
+		ClientSlot:            math.MaxInt32,
+		X:                     math.MinInt32,
+		Y:                     math.MinInt32,
+		VX:                    math.MinInt32,
+		VY:                    math.MinInt32,
+		IsKeyLeftPressed:      true,
+		IsKeyRightPressed:     true,
+		IsKeyJumpPressed:      true,
+		Changed:               math.MaxUint32,
+		ConnectionInterrupted: true,
+	}
+	snapshot := &WorldSnapshot{
+		Tick:          math.MaxUint64,
+		BaselineTick:  math.MaxUint64,
+		InputSequence: math.MaxUint32,
+	}
+
+	// Each player is a tag, a length and the player
+	playerSize := player.Size()
+	entrySize := 1 + sovWorldSnapshot(uint64(playerSize)) + playerSize
+	size := 1 + int64(snapshot.Size()) + int64(maxClients)*int64(entrySize)
+	if size < maxOtherMessageSize {
+		return maxOtherMessageSize
+	}
+	return size
+}
@@ -0,0 +1,36 @@
+package netmsg
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMaxMessageSizeFitsFullSnapshot(t *testing.T) {
+	// Players as far from the origin and as fast as they can be sent
+	q := Quantizer{Precision: MaxPositionPrecision}
+	for _, maxClients := range []int32{1, 16, 256} {
+		players := make(Players)
+		for i := int32(0); i < maxClients; i++ {
+			players[i] = &PlayerState{
+				ClientSlot:            i,
+				X:                     q.Encode(-32767),
+				Y:                     q.Encode(-32767),
+				VX:                    q.Encode(-32767),
+				VY:                    q.Encode(-32767),
+				IsKeyLeftPressed:      true,
+				IsKeyRightPressed:     true,
+				IsKeyJumpPressed:      true,
+				ConnectionInterrupted: true,
+			}
+		}
+		snapshot := NewDeltaSnapshot(math.MaxUint64, 0, nil, players)
+		snapshot.InputSequence = math.MaxUint32
+		packetData, err := Encode(snapshot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if max := MaxMessageSize(maxClients); int64(len(packetData)) > max {
+			t.Fatalf("%d players: snapshot is %d bytes, more than the limit of %d", maxClients, len(packetData), max)
+		}
+	}
+}
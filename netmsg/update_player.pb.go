@@ -1,24 +1,15 @@
 // Code generated by protoc-gen-gogo. DO NOT EDIT.
 // source: update_player.proto
 
-/*
-	Package netmsg is a generated protocol buffer package.
-
-	It is generated from these files:
-		update_player.proto
-
-	It has these top-level messages:
-		UpdatePlayer
-*/
 package netmsg
 
-import proto "github.com/golang/protobuf/proto"
-import fmt "fmt"
-import math "math"
-
-import binary "encoding/binary"
-
-import io "io"
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	io "io"
+	math "math"
+	math_bits "math/bits"
+)
 
 // Reference imports to suppress errors if they are not otherwise used.
 var _ = proto.Marshal
@@ -29,36 +20,69 @@ var _ = math.Inf
 // is compatible with the proto package it is being compiled against.
 // A compilation error at this line likely means your copy of the
 // proto package needs to be updated.
-const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+// UpdatePlayer is sent by clients with their inputs.
 type UpdatePlayer struct {
-	ClientSlot        int32   `protobuf:"varint,1,opt,name=ClientSlot,proto3" json:"ClientSlot,omitempty"`
-	X                 float64 `protobuf:"fixed64,2,opt,name=X,proto3" json:"X,omitempty"`
-	Y                 float64 `protobuf:"fixed64,3,opt,name=Y,proto3" json:"Y,omitempty"`
-	IsKeyLeftPressed  bool    `protobuf:"varint,4,opt,name=IsKeyLeftPressed,proto3" json:"IsKeyLeftPressed,omitempty"`
-	IsKeyRightPressed bool    `protobuf:"varint,5,opt,name=IsKeyRightPressed,proto3" json:"IsKeyRightPressed,omitempty"`
+	// Where the client was before applying this input, fixed-point with
+	// the precision given in ConnectResponse
+	X                 int32 `protobuf:"zigzag32,2,opt,name=X,proto3" json:"X,omitempty"`
+	Y                 int32 `protobuf:"zigzag32,3,opt,name=Y,proto3" json:"Y,omitempty"`
+	IsKeyLeftPressed  bool  `protobuf:"varint,4,opt,name=IsKeyLeftPressed,proto3" json:"IsKeyLeftPressed,omitempty"`
+	IsKeyRightPressed bool  `protobuf:"varint,5,opt,name=IsKeyRightPressed,proto3" json:"IsKeyRightPressed,omitempty"`
+	IsKeyJumpPressed  bool  `protobuf:"varint,6,opt,name=IsKeyJumpPressed,proto3" json:"IsKeyJumpPressed,omitempty"`
+	// Increases by one with each input
+	InputSequence uint32 `protobuf:"varint,9,opt,name=InputSequence,proto3" json:"InputSequence,omitempty"`
+	// Simulation tick the input is for
+	Tick uint64 `protobuf:"varint,10,opt,name=Tick,proto3" json:"Tick,omitempty"`
+	// Tick of the newest snapshot the client has applied
+	SnapshotAck          uint64   `protobuf:"varint,11,opt,name=SnapshotAck,proto3" json:"SnapshotAck,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *UpdatePlayer) Reset()                    { *m = UpdatePlayer{} }
-func (m *UpdatePlayer) String() string            { return proto.CompactTextString(m) }
-func (*UpdatePlayer) ProtoMessage()               {}
-func (*UpdatePlayer) Descriptor() ([]byte, []int) { return fileDescriptorUpdatePlayer, []int{0} }
-
-func (m *UpdatePlayer) GetClientSlot() int32 {
-	if m != nil {
-		return m.ClientSlot
+func (m *UpdatePlayer) Reset()         { *m = UpdatePlayer{} }
+func (m *UpdatePlayer) String() string { return proto.CompactTextString(m) }
+func (*UpdatePlayer) ProtoMessage()    {}
+func (*UpdatePlayer) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5eec4b2b6b3e695, []int{0}
+}
+func (m *UpdatePlayer) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *UpdatePlayer) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_UpdatePlayer.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	return 0
 }
+func (m *UpdatePlayer) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdatePlayer.Merge(m, src)
+}
+func (m *UpdatePlayer) XXX_Size() int {
+	return m.Size()
+}
+func (m *UpdatePlayer) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdatePlayer.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdatePlayer proto.InternalMessageInfo
 
-func (m *UpdatePlayer) GetX() float64 {
+func (m *UpdatePlayer) GetX() int32 {
 	if m != nil {
 		return m.X
 	}
 	return 0
 }
 
-func (m *UpdatePlayer) GetY() float64 {
+func (m *UpdatePlayer) GetY() int32 {
 	if m != nil {
 		return m.Y
 	}
@@ -79,13 +103,64 @@ func (m *UpdatePlayer) GetIsKeyRightPressed() bool {
 	return false
 }
 
+func (m *UpdatePlayer) GetIsKeyJumpPressed() bool {
+	if m != nil {
+		return m.IsKeyJumpPressed
+	}
+	return false
+}
+
+func (m *UpdatePlayer) GetInputSequence() uint32 {
+	if m != nil {
+		return m.InputSequence
+	}
+	return 0
+}
+
+func (m *UpdatePlayer) GetTick() uint64 {
+	if m != nil {
+		return m.Tick
+	}
+	return 0
+}
+
+func (m *UpdatePlayer) GetSnapshotAck() uint64 {
+	if m != nil {
+		return m.SnapshotAck
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*UpdatePlayer)(nil), "netmsg.UpdatePlayer")
 }
+
+func init() { proto.RegisterFile("update_player.proto", fileDescriptor_a5eec4b2b6b3e695) }
+
+var fileDescriptor_a5eec4b2b6b3e695 = []byte{
+	// 248 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x12, 0x2e, 0x2d, 0x48, 0x49,
+	0x2c, 0x49, 0x8d, 0x2f, 0xc8, 0x49, 0xac, 0x4c, 0x2d, 0xd2, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17,
+	0x62, 0xcb, 0x4b, 0x2d, 0xc9, 0x2d, 0x4e, 0x57, 0x9a, 0xc7, 0xc4, 0xc5, 0x13, 0x0a, 0x96, 0x0f,
+	0x00, 0x4b, 0x0b, 0xf1, 0x70, 0x31, 0x46, 0x48, 0x30, 0x29, 0x30, 0x6a, 0x08, 0x06, 0x31, 0x46,
+	0x80, 0x78, 0x91, 0x12, 0xcc, 0x10, 0x5e, 0xa4, 0x90, 0x16, 0x97, 0x80, 0x67, 0xb1, 0x77, 0x6a,
+	0xa5, 0x4f, 0x6a, 0x5a, 0x49, 0x40, 0x51, 0x6a, 0x71, 0x71, 0x6a, 0x8a, 0x04, 0x8b, 0x02, 0xa3,
+	0x06, 0x47, 0x10, 0x86, 0xb8, 0x90, 0x0e, 0x97, 0x20, 0x58, 0x2c, 0x28, 0x33, 0x3d, 0x03, 0xae,
+	0x98, 0x15, 0xac, 0x18, 0x53, 0x02, 0x6e, 0xb2, 0x57, 0x69, 0x6e, 0x01, 0x4c, 0x31, 0x1b, 0x92,
+	0xc9, 0x48, 0xe2, 0x42, 0x2a, 0x5c, 0xbc, 0x9e, 0x79, 0x05, 0xa5, 0x25, 0xc1, 0xa9, 0x85, 0xa5,
+	0xa9, 0x79, 0xc9, 0xa9, 0x12, 0x9c, 0x0a, 0x8c, 0x1a, 0xbc, 0x41, 0xa8, 0x82, 0x42, 0x42, 0x5c,
+	0x2c, 0x21, 0x99, 0xc9, 0xd9, 0x12, 0x5c, 0x0a, 0x8c, 0x1a, 0x2c, 0x41, 0x60, 0xb6, 0x90, 0x02,
+	0x17, 0x77, 0x70, 0x5e, 0x62, 0x41, 0x71, 0x46, 0x7e, 0x89, 0x63, 0x72, 0xb6, 0x04, 0x37, 0x58,
+	0x0a, 0x59, 0xc8, 0x8b, 0x85, 0x83, 0x51, 0x80, 0xc9, 0x8b, 0x85, 0x83, 0x5d, 0x80, 0xc3, 0x8b,
+	0x85, 0x83, 0x43, 0x80, 0xd3, 0x49, 0xe0, 0xc4, 0x23, 0x39, 0xc6, 0x0b, 0x8f, 0xe4, 0x18, 0x1f,
+	0x3c, 0x92, 0x63, 0x9c, 0xf1, 0x58, 0x8e, 0x21, 0x89, 0x0d, 0x1c, 0x82, 0xc6, 0x80, 0x01, 0x00,
+	0x4e, 0xf0, 0x84, 0x1b, 0x58, 0x01, 0x00, 0x00,
+}
+
 func (m *UpdatePlayer) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
-	n, err := m.MarshalTo(dAtA)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
 	if err != nil {
 		return nil, err
 	}
@@ -93,70 +168,99 @@ func (m *UpdatePlayer) Marshal() (dAtA []byte, err error) {
 }
 
 func (m *UpdatePlayer) MarshalTo(dAtA []byte) (int, error) {
-	var i int
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *UpdatePlayer) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
 	_ = i
 	var l int
 	_ = l
-	if m.ClientSlot != 0 {
-		dAtA[i] = 0x8
-		i++
-		i = encodeVarintUpdatePlayer(dAtA, i, uint64(m.ClientSlot))
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	if m.X != 0 {
-		dAtA[i] = 0x11
-		i++
-		binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.X))))
-		i += 8
+	if m.SnapshotAck != 0 {
+		i = encodeVarintUpdatePlayer(dAtA, i, uint64(m.SnapshotAck))
+		i--
+		dAtA[i] = 0x58
 	}
-	if m.Y != 0 {
-		dAtA[i] = 0x19
-		i++
-		binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.Y))))
-		i += 8
+	if m.Tick != 0 {
+		i = encodeVarintUpdatePlayer(dAtA, i, uint64(m.Tick))
+		i--
+		dAtA[i] = 0x50
 	}
-	if m.IsKeyLeftPressed {
-		dAtA[i] = 0x20
-		i++
-		if m.IsKeyLeftPressed {
+	if m.InputSequence != 0 {
+		i = encodeVarintUpdatePlayer(dAtA, i, uint64(m.InputSequence))
+		i--
+		dAtA[i] = 0x48
+	}
+	if m.IsKeyJumpPressed {
+		i--
+		if m.IsKeyJumpPressed {
 			dAtA[i] = 1
 		} else {
 			dAtA[i] = 0
 		}
-		i++
+		i--
+		dAtA[i] = 0x30
 	}
 	if m.IsKeyRightPressed {
-		dAtA[i] = 0x28
-		i++
+		i--
 		if m.IsKeyRightPressed {
 			dAtA[i] = 1
 		} else {
 			dAtA[i] = 0
 		}
-		i++
+		i--
+		dAtA[i] = 0x28
 	}
-	return i, nil
+	if m.IsKeyLeftPressed {
+		i--
+		if m.IsKeyLeftPressed {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.Y != 0 {
+		i = encodeVarintUpdatePlayer(dAtA, i, uint64((uint32(m.Y)<<1)^uint32((m.Y>>31))))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.X != 0 {
+		i = encodeVarintUpdatePlayer(dAtA, i, uint64((uint32(m.X)<<1)^uint32((m.X>>31))))
+		i--
+		dAtA[i] = 0x10
+	}
+	return len(dAtA) - i, nil
 }
 
 func encodeVarintUpdatePlayer(dAtA []byte, offset int, v uint64) int {
+	offset -= sovUpdatePlayer(v)
+	base := offset
 	for v >= 1<<7 {
 		dAtA[offset] = uint8(v&0x7f | 0x80)
 		v >>= 7
 		offset++
 	}
 	dAtA[offset] = uint8(v)
-	return offset + 1
+	return base
 }
 func (m *UpdatePlayer) Size() (n int) {
+	if m == nil {
+		return 0
+	}
 	var l int
 	_ = l
-	if m.ClientSlot != 0 {
-		n += 1 + sovUpdatePlayer(uint64(m.ClientSlot))
-	}
 	if m.X != 0 {
-		n += 9
+		n += 1 + sozUpdatePlayer(uint64(m.X))
 	}
 	if m.Y != 0 {
-		n += 9
+		n += 1 + sozUpdatePlayer(uint64(m.Y))
 	}
 	if m.IsKeyLeftPressed {
 		n += 2
@@ -164,18 +268,26 @@ func (m *UpdatePlayer) Size() (n int) {
 	if m.IsKeyRightPressed {
 		n += 2
 	}
+	if m.IsKeyJumpPressed {
+		n += 2
+	}
+	if m.InputSequence != 0 {
+		n += 1 + sovUpdatePlayer(uint64(m.InputSequence))
+	}
+	if m.Tick != 0 {
+		n += 1 + sovUpdatePlayer(uint64(m.Tick))
+	}
+	if m.SnapshotAck != 0 {
+		n += 1 + sovUpdatePlayer(uint64(m.SnapshotAck))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
 	return n
 }
 
 func sovUpdatePlayer(x uint64) (n int) {
-	for {
-		n++
-		x >>= 7
-		if x == 0 {
-			break
-		}
-	}
-	return n
+	return (math_bits.Len64(x|1) + 6) / 7
 }
 func sozUpdatePlayer(x uint64) (n int) {
 	return sovUpdatePlayer(uint64((x << 1) ^ uint64((int64(x) >> 63))))
@@ -195,7 +307,7 @@ func (m *UpdatePlayer) Unmarshal(dAtA []byte) error {
 			}
 			b := dAtA[iNdEx]
 			iNdEx++
-			wire |= (uint64(b) & 0x7F) << shift
+			wire |= uint64(b&0x7F) << shift
 			if b < 0x80 {
 				break
 			}
@@ -209,11 +321,11 @@ func (m *UpdatePlayer) Unmarshal(dAtA []byte) error {
 			return fmt.Errorf("proto: UpdatePlayer: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 1:
+		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ClientSlot", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field X", wireType)
 			}
-			m.ClientSlot = 0
+			var v int32
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowUpdatePlayer
@@ -223,33 +335,34 @@ func (m *UpdatePlayer) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ClientSlot |= (int32(b) & 0x7F) << shift
+				v |= int32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
-			if wireType != 1 {
-				return fmt.Errorf("proto: wrong wireType = %d for field X", wireType)
-			}
-			var v uint64
-			if (iNdEx + 8) > l {
-				return io.ErrUnexpectedEOF
-			}
-			v = uint64(binary.LittleEndian.Uint64(dAtA[iNdEx:]))
-			iNdEx += 8
-			m.X = float64(math.Float64frombits(v))
+			v = int32((uint32(v) >> 1) ^ uint32(((v&1)<<31)>>31))
+			m.X = v
 		case 3:
-			if wireType != 1 {
+			if wireType != 0 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Y", wireType)
 			}
-			var v uint64
-			if (iNdEx + 8) > l {
-				return io.ErrUnexpectedEOF
+			var v int32
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowUpdatePlayer
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			v = uint64(binary.LittleEndian.Uint64(dAtA[iNdEx:]))
-			iNdEx += 8
-			m.Y = float64(math.Float64frombits(v))
+			v = int32((uint32(v) >> 1) ^ uint32(((v&1)<<31)>>31))
+			m.Y = v
 		case 4:
 			if wireType != 0 {
 				return fmt.Errorf("proto: wrong wireType = %d for field IsKeyLeftPressed", wireType)
@@ -264,7 +377,7 @@ func (m *UpdatePlayer) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= (int(b) & 0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -284,24 +397,102 @@ func (m *UpdatePlayer) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= (int(b) & 0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 			m.IsKeyRightPressed = bool(v != 0)
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IsKeyJumpPressed", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowUpdatePlayer
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.IsKeyJumpPressed = bool(v != 0)
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field InputSequence", wireType)
+			}
+			m.InputSequence = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowUpdatePlayer
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.InputSequence |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Tick", wireType)
+			}
+			m.Tick = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowUpdatePlayer
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Tick |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 11:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SnapshotAck", wireType)
+			}
+			m.SnapshotAck = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowUpdatePlayer
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SnapshotAck |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipUpdatePlayer(dAtA[iNdEx:])
 			if err != nil {
 				return err
 			}
-			if skippy < 0 {
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
 				return ErrInvalidLengthUpdatePlayer
 			}
 			if (iNdEx + skippy) > l {
 				return io.ErrUnexpectedEOF
 			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
 			iNdEx += skippy
 		}
 	}
@@ -314,6 +505,7 @@ func (m *UpdatePlayer) Unmarshal(dAtA []byte) error {
 func skipUpdatePlayer(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
+	depth := 0
 	for iNdEx < l {
 		var wire uint64
 		for shift := uint(0); ; shift += 7 {
@@ -345,10 +537,8 @@ func skipUpdatePlayer(dAtA []byte) (n int, err error) {
 					break
 				}
 			}
-			return iNdEx, nil
 		case 1:
 			iNdEx += 8
-			return iNdEx, nil
 		case 2:
 			var length int
 			for shift := uint(0); ; shift += 7 {
@@ -365,71 +555,34 @@ func skipUpdatePlayer(dAtA []byte) (n int, err error) {
 					break
 				}
 			}
-			iNdEx += length
 			if length < 0 {
 				return 0, ErrInvalidLengthUpdatePlayer
 			}
-			return iNdEx, nil
+			iNdEx += length
 		case 3:
-			for {
-				var innerWire uint64
-				var start int = iNdEx
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return 0, ErrIntOverflowUpdatePlayer
-					}
-					if iNdEx >= l {
-						return 0, io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					innerWire |= (uint64(b) & 0x7F) << shift
-					if b < 0x80 {
-						break
-					}
-				}
-				innerWireType := int(innerWire & 0x7)
-				if innerWireType == 4 {
-					break
-				}
-				next, err := skipUpdatePlayer(dAtA[start:])
-				if err != nil {
-					return 0, err
-				}
-				iNdEx = start + next
-			}
-			return iNdEx, nil
+			depth++
 		case 4:
-			return iNdEx, nil
+			if depth == 0 {
+				return 0, ErrUnexpectedEndOfGroupUpdatePlayer
+			}
+			depth--
 		case 5:
 			iNdEx += 4
-			return iNdEx, nil
 		default:
 			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
 		}
+		if iNdEx < 0 {
+			return 0, ErrInvalidLengthUpdatePlayer
+		}
+		if depth == 0 {
+			return iNdEx, nil
+		}
 	}
-	panic("unreachable")
+	return 0, io.ErrUnexpectedEOF
 }
 
 var (
-	ErrInvalidLengthUpdatePlayer = fmt.Errorf("proto: negative length found during unmarshaling")
-	ErrIntOverflowUpdatePlayer   = fmt.Errorf("proto: integer overflow")
+	ErrInvalidLengthUpdatePlayer        = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowUpdatePlayer          = fmt.Errorf("proto: integer overflow")
+	ErrUnexpectedEndOfGroupUpdatePlayer = fmt.Errorf("proto: unexpected end of group")
 )
-
-func init() { proto.RegisterFile("update_player.proto", fileDescriptorUpdatePlayer) }
-
-var fileDescriptorUpdatePlayer = []byte{
-	// 179 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x12, 0x2e, 0x2d, 0x48, 0x49,
-	0x2c, 0x49, 0x8d, 0x2f, 0xc8, 0x49, 0xac, 0x4c, 0x2d, 0xd2, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17,
-	0x62, 0xcb, 0x4b, 0x2d, 0xc9, 0x2d, 0x4e, 0x57, 0x5a, 0xc2, 0xc8, 0xc5, 0x13, 0x0a, 0x96, 0x0f,
-	0x00, 0x4b, 0x0b, 0xc9, 0x71, 0x71, 0x39, 0xe7, 0x64, 0xa6, 0xe6, 0x95, 0x04, 0xe7, 0xe4, 0x97,
-	0x48, 0x30, 0x2a, 0x30, 0x6a, 0xb0, 0x06, 0x21, 0x89, 0x08, 0xf1, 0x70, 0x31, 0x46, 0x48, 0x30,
-	0x29, 0x30, 0x6a, 0x30, 0x06, 0x31, 0x46, 0x80, 0x78, 0x91, 0x12, 0xcc, 0x10, 0x5e, 0xa4, 0x90,
-	0x16, 0x97, 0x80, 0x67, 0xb1, 0x77, 0x6a, 0xa5, 0x4f, 0x6a, 0x5a, 0x49, 0x40, 0x51, 0x6a, 0x71,
-	0x71, 0x6a, 0x8a, 0x04, 0x8b, 0x02, 0xa3, 0x06, 0x47, 0x10, 0x86, 0xb8, 0x90, 0x0e, 0x97, 0x20,
-	0x58, 0x2c, 0x28, 0x33, 0x3d, 0x03, 0xae, 0x98, 0x15, 0xac, 0x18, 0x53, 0xc2, 0x49, 0xe0, 0xc4,
-	0x23, 0x39, 0xc6, 0x0b, 0x8f, 0xe4, 0x18, 0x1f, 0x3c, 0x92, 0x63, 0x9c, 0xf1, 0x58, 0x8e, 0x21,
-	0x89, 0x0d, 0xec, 0x0f, 0x63, 0x40, 0x00, 0x00, 0x00, 0xff, 0xff, 0xba, 0x88, 0x27, 0xa6, 0xde,
-	0x00, 0x00, 0x00,
-}
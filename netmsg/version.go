@@ -0,0 +1,13 @@
+package netmsg
+
+// ProtocolVersion is sent by clients in Hello. Servers only accept clients
+// with the same version, so bump it whenever messages are added or change,
+// and note what changed below.
+//
+//	1: Hello and Reject
+//	2: PlayerJoined, and names in Hello and ConnectResponse
+//	3: ChatSend and ChatMessage
+//	4: Resume tokens in Hello and ConnectResponse
+//	5: PlayerState.ConnectionInterrupted
+//	6: Ping and Pong
+const ProtocolVersion = 6
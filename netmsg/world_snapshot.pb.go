@@ -0,0 +1,1090 @@
+// Code generated by protoc-gen-gogo. DO NOT EDIT.
+// source: world_snapshot.proto
+
+package netmsg
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	io "io"
+	math "math"
+	math_bits "math/bits"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// PlayerState is the server's state for one player. In a delta snapshot
+// only the fields flagged in Changed are set.
+//
+// Positions and velocities are fixed-point, with the precision given in
+// ConnectResponse.
+type PlayerState struct {
+	ClientSlot        int32 `protobuf:"varint,1,opt,name=ClientSlot,proto3" json:"ClientSlot,omitempty"`
+	X                 int32 `protobuf:"zigzag32,2,opt,name=X,proto3" json:"X,omitempty"`
+	Y                 int32 `protobuf:"zigzag32,3,opt,name=Y,proto3" json:"Y,omitempty"`
+	VX                int32 `protobuf:"zigzag32,4,opt,name=VX,proto3" json:"VX,omitempty"`
+	VY                int32 `protobuf:"zigzag32,5,opt,name=VY,proto3" json:"VY,omitempty"`
+	IsKeyLeftPressed  bool  `protobuf:"varint,6,opt,name=IsKeyLeftPressed,proto3" json:"IsKeyLeftPressed,omitempty"`
+	IsKeyRightPressed bool  `protobuf:"varint,7,opt,name=IsKeyRightPressed,proto3" json:"IsKeyRightPressed,omitempty"`
+	IsKeyJumpPressed  bool  `protobuf:"varint,8,opt,name=IsKeyJumpPressed,proto3" json:"IsKeyJumpPressed,omitempty"`
+	// Bitmask of the fields set, see FieldX and friends
+	Changed uint32 `protobuf:"varint,10,opt,name=Changed,proto3" json:"Changed,omitempty"`
+	// The player lost connection and is frozen until they reconnect
+	ConnectionInterrupted bool     `protobuf:"varint,11,opt,name=ConnectionInterrupted,proto3" json:"ConnectionInterrupted,omitempty"`
+	XXX_NoUnkeyedLiteral  struct{} `json:"-"`
+	XXX_unrecognized      []byte   `json:"-"`
+	XXX_sizecache         int32    `json:"-"`
+}
+
+func (m *PlayerState) Reset()         { *m = PlayerState{} }
+func (m *PlayerState) String() string { return proto.CompactTextString(m) }
+func (*PlayerState) ProtoMessage()    {}
+func (*PlayerState) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e0602cc017820eb, []int{0}
+}
+func (m *PlayerState) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *PlayerState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PlayerState.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *PlayerState) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PlayerState.Merge(m, src)
+}
+func (m *PlayerState) XXX_Size() int {
+	return m.Size()
+}
+func (m *PlayerState) XXX_DiscardUnknown() {
+	xxx_messageInfo_PlayerState.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PlayerState proto.InternalMessageInfo
+
+func (m *PlayerState) GetClientSlot() int32 {
+	if m != nil {
+		return m.ClientSlot
+	}
+	return 0
+}
+
+func (m *PlayerState) GetX() int32 {
+	if m != nil {
+		return m.X
+	}
+	return 0
+}
+
+func (m *PlayerState) GetY() int32 {
+	if m != nil {
+		return m.Y
+	}
+	return 0
+}
+
+func (m *PlayerState) GetVX() int32 {
+	if m != nil {
+		return m.VX
+	}
+	return 0
+}
+
+func (m *PlayerState) GetVY() int32 {
+	if m != nil {
+		return m.VY
+	}
+	return 0
+}
+
+func (m *PlayerState) GetIsKeyLeftPressed() bool {
+	if m != nil {
+		return m.IsKeyLeftPressed
+	}
+	return false
+}
+
+func (m *PlayerState) GetIsKeyRightPressed() bool {
+	if m != nil {
+		return m.IsKeyRightPressed
+	}
+	return false
+}
+
+func (m *PlayerState) GetIsKeyJumpPressed() bool {
+	if m != nil {
+		return m.IsKeyJumpPressed
+	}
+	return false
+}
+
+func (m *PlayerState) GetChanged() uint32 {
+	if m != nil {
+		return m.Changed
+	}
+	return 0
+}
+
+func (m *PlayerState) GetConnectionInterrupted() bool {
+	if m != nil {
+		return m.ConnectionInterrupted
+	}
+	return false
+}
+
+// WorldSnapshot is the state of every player at a server tick. The tick
+// also identifies the snapshot, clients acknowledge it in UpdatePlayer.
+//
+// If BaselineTick is set, the snapshot only has what changed since that
+// snapshot. Players that didn't change are left out and players that left
+// are listed in RemovedSlots.
+type WorldSnapshot struct {
+	Tick         uint64         `protobuf:"varint,1,opt,name=Tick,proto3" json:"Tick,omitempty"`
+	Players      []*PlayerState `protobuf:"bytes,2,rep,name=Players,proto3" json:"Players,omitempty"`
+	BaselineTick uint64         `protobuf:"varint,3,opt,name=BaselineTick,proto3" json:"BaselineTick,omitempty"`
+	RemovedSlots []int32        `protobuf:"varint,4,rep,packed,name=RemovedSlots,proto3" json:"RemovedSlots,omitempty"`
+	// Last input the server processed for the receiving player
+	InputSequence        uint32   `protobuf:"varint,5,opt,name=InputSequence,proto3" json:"InputSequence,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WorldSnapshot) Reset()         { *m = WorldSnapshot{} }
+func (m *WorldSnapshot) String() string { return proto.CompactTextString(m) }
+func (*WorldSnapshot) ProtoMessage()    {}
+func (*WorldSnapshot) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e0602cc017820eb, []int{1}
+}
+func (m *WorldSnapshot) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *WorldSnapshot) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_WorldSnapshot.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *WorldSnapshot) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WorldSnapshot.Merge(m, src)
+}
+func (m *WorldSnapshot) XXX_Size() int {
+	return m.Size()
+}
+func (m *WorldSnapshot) XXX_DiscardUnknown() {
+	xxx_messageInfo_WorldSnapshot.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WorldSnapshot proto.InternalMessageInfo
+
+func (m *WorldSnapshot) GetTick() uint64 {
+	if m != nil {
+		return m.Tick
+	}
+	return 0
+}
+
+func (m *WorldSnapshot) GetPlayers() []*PlayerState {
+	if m != nil {
+		return m.Players
+	}
+	return nil
+}
+
+func (m *WorldSnapshot) GetBaselineTick() uint64 {
+	if m != nil {
+		return m.BaselineTick
+	}
+	return 0
+}
+
+func (m *WorldSnapshot) GetRemovedSlots() []int32 {
+	if m != nil {
+		return m.RemovedSlots
+	}
+	return nil
+}
+
+func (m *WorldSnapshot) GetInputSequence() uint32 {
+	if m != nil {
+		return m.InputSequence
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*PlayerState)(nil), "netmsg.PlayerState")
+	proto.RegisterType((*WorldSnapshot)(nil), "netmsg.WorldSnapshot")
+}
+
+func init() { proto.RegisterFile("world_snapshot.proto", fileDescriptor_6e0602cc017820eb) }
+
+var fileDescriptor_6e0602cc017820eb = []byte{
+	// 368 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x92, 0xcd, 0x8e, 0xd3, 0x30,
+	0x14, 0x85, 0x71, 0x92, 0xfe, 0x70, 0xdb, 0xa0, 0xd6, 0x80, 0xe4, 0x55, 0x14, 0x55, 0x2c, 0x22,
+	0x04, 0x5d, 0x00, 0x4f, 0xd0, 0xae, 0x5a, 0x58, 0x54, 0x0e, 0x2a, 0xc9, 0x0a, 0x85, 0xe6, 0xd2,
+	0x46, 0xa4, 0x76, 0x88, 0x1d, 0x50, 0xdf, 0x84, 0xc7, 0x61, 0x33, 0xd2, 0x2c, 0xe7, 0x11, 0x46,
+	0x9d, 0x17, 0x19, 0xc5, 0xfd, 0x51, 0xaa, 0xce, 0xce, 0xe7, 0xbb, 0x47, 0x67, 0x71, 0x8e, 0xe1,
+	0xd5, 0x5f, 0x59, 0xe6, 0xe9, 0x77, 0x25, 0x92, 0x42, 0x6d, 0xa4, 0x1e, 0x17, 0xa5, 0xd4, 0x92,
+	0xb6, 0x05, 0xea, 0xad, 0x5a, 0x8f, 0x6e, 0x2c, 0xe8, 0x2d, 0xf2, 0x64, 0x87, 0x65, 0xa8, 0x13,
+	0x8d, 0xd4, 0x03, 0x98, 0xe6, 0x19, 0x0a, 0x1d, 0xe6, 0x52, 0x33, 0xe2, 0x93, 0xa0, 0xc5, 0x1b,
+	0x84, 0xf6, 0x81, 0x44, 0xcc, 0xf2, 0x49, 0x30, 0xe4, 0x24, 0xaa, 0x55, 0xcc, 0xec, 0x83, 0x8a,
+	0xe9, 0x0b, 0xb0, 0x96, 0x11, 0x73, 0x8c, 0xb4, 0x96, 0x91, 0xd1, 0x31, 0x6b, 0x1d, 0x75, 0x4c,
+	0xdf, 0xc2, 0x60, 0xa6, 0x3e, 0xe3, 0xee, 0x0b, 0xfe, 0xd4, 0x8b, 0x12, 0x95, 0xc2, 0x94, 0xb5,
+	0x7d, 0x12, 0x74, 0xf9, 0x15, 0xa7, 0xef, 0x60, 0x68, 0x18, 0xcf, 0xd6, 0x9b, 0xb3, 0xb9, 0x63,
+	0xcc, 0xd7, 0x87, 0x73, 0xf2, 0xbc, 0xda, 0x16, 0x27, 0x73, 0xb7, 0x91, 0xdc, 0xe0, 0x94, 0x41,
+	0x67, 0xba, 0x49, 0xc4, 0x1a, 0x53, 0x06, 0x3e, 0x09, 0x5c, 0x7e, 0x92, 0xf4, 0x13, 0xbc, 0x9e,
+	0x4a, 0x21, 0x70, 0xa5, 0x33, 0x29, 0x66, 0x42, 0x63, 0x59, 0x56, 0x85, 0xc6, 0x94, 0xf5, 0x4c,
+	0xd4, 0xd3, 0xc7, 0xb9, 0xd3, 0x7d, 0x3e, 0x80, 0xd1, 0x7f, 0x02, 0xee, 0xb7, 0xba, 0xe8, 0xf0,
+	0xd8, 0x33, 0xa5, 0xe0, 0x7c, 0xcd, 0x56, 0xbf, 0x4c, 0x87, 0x0e, 0x37, 0x6f, 0xfa, 0x1e, 0x3a,
+	0x87, 0xb2, 0x15, 0xb3, 0x7c, 0x3b, 0xe8, 0x7d, 0x78, 0x39, 0x3e, 0xec, 0x30, 0x6e, 0x6c, 0xc0,
+	0x4f, 0x1e, 0x3a, 0x82, 0xfe, 0x24, 0x51, 0x98, 0x67, 0x02, 0x4d, 0x94, 0x6d, 0xa2, 0x2e, 0x58,
+	0xed, 0xe1, 0xb8, 0x95, 0x7f, 0x30, 0xad, 0xf7, 0x51, 0xcc, 0xf1, 0xed, 0xa0, 0xc5, 0x2f, 0x18,
+	0x7d, 0x03, 0xee, 0x4c, 0x14, 0x95, 0x0e, 0xf1, 0x77, 0x85, 0x62, 0x85, 0x66, 0x13, 0x97, 0x5f,
+	0xc2, 0xc9, 0xe0, 0x76, 0xef, 0x91, 0xbb, 0xbd, 0x47, 0xee, 0xf7, 0x1e, 0xf9, 0xf7, 0xe0, 0x3d,
+	0xfb, 0xd1, 0x36, 0x7f, 0xe5, 0xe3, 0xe3, 0x00, 0x7e, 0x59, 0xd7, 0xa3, 0x43, 0x02, 0x00, 0x00,
+}
+
+func (m *PlayerState) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PlayerState) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PlayerState) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.ConnectionInterrupted {
+		i--
+		if m.ConnectionInterrupted {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x58
+	}
+	if m.Changed != 0 {
+		i = encodeVarintWorldSnapshot(dAtA, i, uint64(m.Changed))
+		i--
+		dAtA[i] = 0x50
+	}
+	if m.IsKeyJumpPressed {
+		i--
+		if m.IsKeyJumpPressed {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x40
+	}
+	if m.IsKeyRightPressed {
+		i--
+		if m.IsKeyRightPressed {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.IsKeyLeftPressed {
+		i--
+		if m.IsKeyLeftPressed {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.VY != 0 {
+		i = encodeVarintWorldSnapshot(dAtA, i, uint64((uint32(m.VY)<<1)^uint32((m.VY>>31))))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.VX != 0 {
+		i = encodeVarintWorldSnapshot(dAtA, i, uint64((uint32(m.VX)<<1)^uint32((m.VX>>31))))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.Y != 0 {
+		i = encodeVarintWorldSnapshot(dAtA, i, uint64((uint32(m.Y)<<1)^uint32((m.Y>>31))))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.X != 0 {
+		i = encodeVarintWorldSnapshot(dAtA, i, uint64((uint32(m.X)<<1)^uint32((m.X>>31))))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.ClientSlot != 0 {
+		i = encodeVarintWorldSnapshot(dAtA, i, uint64(m.ClientSlot))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *WorldSnapshot) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *WorldSnapshot) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *WorldSnapshot) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.InputSequence != 0 {
+		i = encodeVarintWorldSnapshot(dAtA, i, uint64(m.InputSequence))
+		i--
+		dAtA[i] = 0x28
+	}
+	if len(m.RemovedSlots) > 0 {
+		dAtA2 := make([]byte, len(m.RemovedSlots)*10)
+		var j1 int
+		for _, num1 := range m.RemovedSlots {
+			num := uint64(num1)
+			for num >= 1<<7 {
+				dAtA2[j1] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j1++
+			}
+			dAtA2[j1] = uint8(num)
+			j1++
+		}
+		i -= j1
+		copy(dAtA[i:], dAtA2[:j1])
+		i = encodeVarintWorldSnapshot(dAtA, i, uint64(j1))
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.BaselineTick != 0 {
+		i = encodeVarintWorldSnapshot(dAtA, i, uint64(m.BaselineTick))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Players) > 0 {
+		for iNdEx := len(m.Players) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Players[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintWorldSnapshot(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if m.Tick != 0 {
+		i = encodeVarintWorldSnapshot(dAtA, i, uint64(m.Tick))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintWorldSnapshot(dAtA []byte, offset int, v uint64) int {
+	offset -= sovWorldSnapshot(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *PlayerState) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ClientSlot != 0 {
+		n += 1 + sovWorldSnapshot(uint64(m.ClientSlot))
+	}
+	if m.X != 0 {
+		n += 1 + sozWorldSnapshot(uint64(m.X))
+	}
+	if m.Y != 0 {
+		n += 1 + sozWorldSnapshot(uint64(m.Y))
+	}
+	if m.VX != 0 {
+		n += 1 + sozWorldSnapshot(uint64(m.VX))
+	}
+	if m.VY != 0 {
+		n += 1 + sozWorldSnapshot(uint64(m.VY))
+	}
+	if m.IsKeyLeftPressed {
+		n += 2
+	}
+	if m.IsKeyRightPressed {
+		n += 2
+	}
+	if m.IsKeyJumpPressed {
+		n += 2
+	}
+	if m.Changed != 0 {
+		n += 1 + sovWorldSnapshot(uint64(m.Changed))
+	}
+	if m.ConnectionInterrupted {
+		n += 2
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *WorldSnapshot) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Tick != 0 {
+		n += 1 + sovWorldSnapshot(uint64(m.Tick))
+	}
+	if len(m.Players) > 0 {
+		for _, e := range m.Players {
+			l = e.Size()
+			n += 1 + l + sovWorldSnapshot(uint64(l))
+		}
+	}
+	if m.BaselineTick != 0 {
+		n += 1 + sovWorldSnapshot(uint64(m.BaselineTick))
+	}
+	if len(m.RemovedSlots) > 0 {
+		l = 0
+		for _, e := range m.RemovedSlots {
+			l += sovWorldSnapshot(uint64(e))
+		}
+		n += 1 + sovWorldSnapshot(uint64(l)) + l
+	}
+	if m.InputSequence != 0 {
+		n += 1 + sovWorldSnapshot(uint64(m.InputSequence))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func sovWorldSnapshot(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozWorldSnapshot(x uint64) (n int) {
+	return sovWorldSnapshot(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *PlayerState) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowWorldSnapshot
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: PlayerState: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: PlayerState: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ClientSlot", wireType)
+			}
+			m.ClientSlot = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorldSnapshot
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ClientSlot |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field X", wireType)
+			}
+			var v int32
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorldSnapshot
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			v = int32((uint32(v) >> 1) ^ uint32(((v&1)<<31)>>31))
+			m.X = v
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Y", wireType)
+			}
+			var v int32
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorldSnapshot
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			v = int32((uint32(v) >> 1) ^ uint32(((v&1)<<31)>>31))
+			m.Y = v
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field VX", wireType)
+			}
+			var v int32
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorldSnapshot
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			v = int32((uint32(v) >> 1) ^ uint32(((v&1)<<31)>>31))
+			m.VX = v
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field VY", wireType)
+			}
+			var v int32
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorldSnapshot
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			v = int32((uint32(v) >> 1) ^ uint32(((v&1)<<31)>>31))
+			m.VY = v
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IsKeyLeftPressed", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorldSnapshot
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.IsKeyLeftPressed = bool(v != 0)
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IsKeyRightPressed", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorldSnapshot
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.IsKeyRightPressed = bool(v != 0)
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IsKeyJumpPressed", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorldSnapshot
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.IsKeyJumpPressed = bool(v != 0)
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Changed", wireType)
+			}
+			m.Changed = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorldSnapshot
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Changed |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 11:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ConnectionInterrupted", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorldSnapshot
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.ConnectionInterrupted = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipWorldSnapshot(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthWorldSnapshot
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *WorldSnapshot) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowWorldSnapshot
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: WorldSnapshot: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: WorldSnapshot: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Tick", wireType)
+			}
+			m.Tick = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorldSnapshot
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Tick |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Players", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorldSnapshot
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthWorldSnapshot
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthWorldSnapshot
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Players = append(m.Players, &PlayerState{})
+			if err := m.Players[len(m.Players)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BaselineTick", wireType)
+			}
+			m.BaselineTick = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorldSnapshot
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BaselineTick |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType == 0 {
+				var v int32
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowWorldSnapshot
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= int32(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.RemovedSlots = append(m.RemovedSlots, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowWorldSnapshot
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthWorldSnapshot
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthWorldSnapshot
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				var count int
+				for _, integer := range dAtA[iNdEx:postIndex] {
+					if integer < 128 {
+						count++
+					}
+				}
+				elementCount = count
+				if elementCount != 0 && len(m.RemovedSlots) == 0 {
+					m.RemovedSlots = make([]int32, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v int32
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowWorldSnapshot
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= int32(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.RemovedSlots = append(m.RemovedSlots, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field RemovedSlots", wireType)
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field InputSequence", wireType)
+			}
+			m.InputSequence = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorldSnapshot
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.InputSequence |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipWorldSnapshot(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthWorldSnapshot
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func skipWorldSnapshot(dAtA []byte) (n int, err error) {
+	l := len(dAtA)
+	iNdEx := 0
+	depth := 0
+	for iNdEx < l {
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return 0, ErrIntOverflowWorldSnapshot
+			}
+			if iNdEx >= l {
+				return 0, io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		wireType := int(wire & 0x7)
+		switch wireType {
+		case 0:
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowWorldSnapshot
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				iNdEx++
+				if dAtA[iNdEx-1] < 0x80 {
+					break
+				}
+			}
+		case 1:
+			iNdEx += 8
+		case 2:
+			var length int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowWorldSnapshot
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				length |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if length < 0 {
+				return 0, ErrInvalidLengthWorldSnapshot
+			}
+			iNdEx += length
+		case 3:
+			depth++
+		case 4:
+			if depth == 0 {
+				return 0, ErrUnexpectedEndOfGroupWorldSnapshot
+			}
+			depth--
+		case 5:
+			iNdEx += 4
+		default:
+			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
+		}
+		if iNdEx < 0 {
+			return 0, ErrInvalidLengthWorldSnapshot
+		}
+		if depth == 0 {
+			return iNdEx, nil
+		}
+	}
+	return 0, io.ErrUnexpectedEOF
+}
+
+var (
+	ErrInvalidLengthWorldSnapshot        = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowWorldSnapshot          = fmt.Errorf("proto: integer overflow")
+	ErrUnexpectedEndOfGroupWorldSnapshot = fmt.Errorf("proto: unexpected end of group")
+)
@@ -0,0 +1,224 @@
+// Package netsim simulates a bad network on top of a good one, by delaying,
+// dropping, duplicating and reordering messages. It is used to test how the
+// game plays over the internet without leaving localhost.
+package netsim
+
+import (
+	"container/heap"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Config is how bad the simulated network is. It applies to each direction
+// separately, so a Latency of 50ms adds 100ms to the round-trip time when
+// used for both sending and receiving.
+type Config struct {
+	// Delay added to every message
+	Latency time.Duration
+
+	// Messages are delayed by up to this much more or less than Latency
+	Jitter time.Duration
+
+	// Chance of a message being dropped, from 0 to 1
+	Loss float64
+
+	// Chance of a message being delivered twice, from 0 to 1
+	Duplicate float64
+
+	// Chance of a message being allowed to arrive before messages sent
+	// before it, from 0 to 1. Without this, jitter never reorders messages.
+	Reorder float64
+}
+
+var ErrBadChance = errors.New("Simulated network chances must be from 0 to 1.")
+
+// Enabled reports whether the config changes anything.
+func (c Config) Enabled() bool {
+	return c != Config{}
+}
+
+// Validate checks the chances are from 0 to 1.
+func (c Config) Validate() error {
+	for _, chance := range []float64{c.Loss, c.Duplicate, c.Reorder} {
+		if chance < 0 || chance > 1 {
+			return ErrBadChance
+		}
+	}
+	return nil
+}
+
+// Link passes messages through a simulated network. Messages that make it
+// are given to the deliver function, one at a time, in the order they
+// arrive.
+type Link struct {
+	config  Config
+	deliver func(data []byte)
+
+	mu     sync.Mutex
+	rand   *rand.Rand
+	queue  messageQueue
+	seq    uint64
+	closed bool
+
+	// When the last message that had to stay in order arrives
+	last time.Time
+
+	wake    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// NewLink starts a link that calls deliver with each message that gets
+// through, until it is closed.
+func NewLink(config Config, deliver func(data []byte)) *Link {
+	l := &Link{
+		config:  config,
+		deliver: deliver,
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go l.run()
+	return l
+}
+
+// Send puts a message on the link.
+func (l *Link) Send(data []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed || l.rand.Float64() < l.config.Loss {
+		return
+	}
+	copies := 1
+	if l.rand.Float64() < l.config.Duplicate {
+		copies = 2
+	}
+	now := time.Now()
+	for i := 0; i < copies; i++ {
+		at := now.Add(l.delay())
+		if l.rand.Float64() >= l.config.Reorder {
+			if at.Before(l.last) {
+				at = l.last
+			}
+			l.last = at
+		}
+		heap.Push(&l.queue, &message{
+			at:   at,
+			seq:  l.seq,
+			data: data,
+		})
+		l.seq++
+	}
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Close stops the link. Messages still in flight are dropped, and once it
+// returns deliver won't be called again.
+func (l *Link) Close() {
+	l.mu.Lock()
+	if !l.closed {
+		l.closed = true
+		l.queue = nil
+		close(l.done)
+	}
+	l.mu.Unlock()
+	<-l.stopped
+}
+
+// delay picks how long a message takes, it must be called with mu held.
+func (l *Link) delay() time.Duration {
+	delay := l.config.Latency
+	if l.config.Jitter > 0 {
+		delay += time.Duration(l.rand.Int63n(int64(2*l.config.Jitter)+1)) - l.config.Jitter
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return delay
+}
+
+// run delivers messages as they arrive.
+func (l *Link) run() {
+	defer close(l.stopped)
+	for {
+		l.mu.Lock()
+		var (
+			due  [][]byte
+			wait time.Duration = -1
+			now                = time.Now()
+		)
+		for len(l.queue) > 0 && !l.queue[0].at.After(now) {
+			due = append(due, heap.Pop(&l.queue).(*message).data)
+		}
+		if len(l.queue) > 0 {
+			wait = l.queue[0].at.Sub(now)
+		}
+		l.mu.Unlock()
+
+		if len(due) > 0 {
+			for _, data := range due {
+				select {
+				case <-l.done:
+					return
+				default:
+				}
+				l.deliver(data)
+			}
+			continue
+		}
+
+		var (
+			timer   *time.Timer
+			timeout <-chan time.Time
+		)
+		if wait >= 0 {
+			timer = time.NewTimer(wait)
+			timeout = timer.C
+		}
+		select {
+		case <-timeout:
+		case <-l.wake:
+		case <-l.done:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		select {
+		case <-l.done:
+			return
+		default:
+		}
+	}
+}
+
+type message struct {
+	at   time.Time
+	seq  uint64
+	data []byte
+}
+
+// messageQueue is a heap of messages, ordered by when they arrive and
+// then by when they were sent.
+type messageQueue []*message
+
+func (q messageQueue) Len() int { return len(q) }
+func (q messageQueue) Less(i, j int) bool {
+	if q[i].at.Equal(q[j].at) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].at.Before(q[j].at)
+}
+func (q messageQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *messageQueue) Push(x interface{}) { *q = append(*q, x.(*message)) }
+func (q *messageQueue) Pop() interface{} {
+	old := *q
+	m := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return m
+}
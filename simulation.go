@@ -0,0 +1,20 @@
+package main
+
+// currentTick counts simulation steps. Clients take the server's count when
+// they connect.
+var currentTick uint64
+
+// Step advances every locally simulated character by dt seconds and counts
+// a tick. dt should always be the length of one tick, so that clients and
+// the server simulate identically regardless of frame rate.
+//
+// Remote players are skipped, they are moved by Client.Interpolate instead.
+func Step(dt float64) {
+	for _, char := range chars {
+		if char.snapshots != nil {
+			continue
+		}
+		char.Step(currentLevel, dt)
+	}
+	currentTick++
+}
@@ -0,0 +1,315 @@
+package transport
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// pipeBufferSize is how many messages can be on their way each direction
+// before WriteMessage blocks.
+const pipeBufferSize = 64
+
+// Pipe creates two connected Conns in memory. Messages written to one are
+// read from the other, and closing works like a websocket's.
+func Pipe() (Conn, Conn) {
+	a, b := newPipeConn(), newPipeConn()
+	a.peer, b.peer = b, a
+	return a, b
+}
+
+type pipeMessage struct {
+	data []byte
+
+	// Set when this is a close message rather than data
+	close *CloseError
+}
+
+type pipeConn struct {
+	// Messages for us to read, written by the peer.
+	recv chan pipeMessage
+	peer *pipeConn
+
+	// Closed by Close.
+	done      chan struct{}
+	closeOnce sync.Once
+
+	readDeadline  pipeDeadline
+	writeDeadline pipeDeadline
+
+	// Error every read returns once one has failed, only used by the
+	// reader.
+	readErr error
+
+	mu          sync.Mutex
+	readLimit   int64
+	pongHandler func()
+	sentClose   bool
+}
+
+func newPipeConn() *pipeConn {
+	return &pipeConn{
+		recv:          make(chan pipeMessage, pipeBufferSize),
+		done:          make(chan struct{}),
+		readDeadline:  makePipeDeadline(),
+		writeDeadline: makePipeDeadline(),
+	}
+}
+
+func (c *pipeConn) ReadMessage() ([]byte, error) {
+	if c.readErr != nil {
+		return nil, c.readErr
+	}
+	select {
+	case <-c.done:
+		return nil, ErrClosed
+	case <-c.readDeadline.wait():
+		c.readErr = ErrTimeout
+		return nil, c.readErr
+	default:
+	}
+	var msg pipeMessage
+	select {
+	case msg = <-c.recv:
+	case <-c.done:
+		return nil, ErrClosed
+	case <-c.readDeadline.wait():
+		c.readErr = ErrTimeout
+		return nil, c.readErr
+	case <-c.peer.done:
+		// Read what they sent before they went away
+		select {
+		case msg = <-c.recv:
+		default:
+			c.readErr = &CloseError{Code: CloseAbnormalClosure}
+			return nil, c.readErr
+		}
+	}
+	if msg.close != nil {
+		// Reply to the close message like a websocket does
+		c.reply(&CloseError{Code: msg.close.Code})
+		c.readErr = msg.close
+		return nil, c.readErr
+	}
+	c.mu.Lock()
+	limit := c.readLimit
+	c.mu.Unlock()
+	if limit > 0 && int64(len(msg.data)) > limit {
+		c.reply(&CloseError{Code: CloseMessageTooBig})
+		c.readErr = ErrReadLimit
+		return nil, c.readErr
+	}
+	return msg.data, nil
+}
+
+// reply sends a close message from the reader, unless one was already
+// sent. It doesn't block, if the peer isn't reading they won't get it.
+func (c *pipeConn) reply(closeErr *CloseError) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sentClose {
+		return
+	}
+	c.sentClose = true
+	select {
+	case c.peer.recv <- pipeMessage{close: closeErr}:
+	default:
+	}
+}
+
+func (c *pipeConn) WriteMessage(data []byte) error {
+	c.mu.Lock()
+	sentClose := c.sentClose
+	c.mu.Unlock()
+	if sentClose {
+		return ErrClosed
+	}
+	return c.write(pipeMessage{data: append([]byte(nil), data...)})
+}
+
+func (c *pipeConn) WriteClose(code int, text string) error {
+	c.mu.Lock()
+	if c.sentClose {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	c.sentClose = true
+	c.mu.Unlock()
+	return c.write(pipeMessage{close: &CloseError{Code: code, Text: text}})
+}
+
+func (c *pipeConn) write(msg pipeMessage) error {
+	select {
+	case <-c.done:
+		return ErrClosed
+	case <-c.peer.done:
+		return ErrClosed
+	case <-c.writeDeadline.wait():
+		return ErrTimeout
+	default:
+	}
+	select {
+	case c.peer.recv <- msg:
+		return nil
+	case <-c.done:
+		return ErrClosed
+	case <-c.peer.done:
+		return ErrClosed
+	case <-c.writeDeadline.wait():
+		return ErrTimeout
+	}
+}
+
+// Ping calls the pong handler straight away, the peer is in the same
+// process so it is there until it is closed.
+func (c *pipeConn) Ping() error {
+	select {
+	case <-c.done:
+		return ErrClosed
+	case <-c.peer.done:
+		return ErrClosed
+	default:
+	}
+	c.mu.Lock()
+	h := c.pongHandler
+	c.mu.Unlock()
+	if h != nil {
+		h()
+	}
+	return nil
+}
+
+func (c *pipeConn) SetPongHandler(h func()) {
+	c.mu.Lock()
+	c.pongHandler = h
+	c.mu.Unlock()
+}
+
+func (c *pipeConn) SetReadLimit(limit int64) {
+	c.mu.Lock()
+	c.readLimit = limit
+	c.mu.Unlock()
+}
+
+func (c *pipeConn) SetReadDeadline(t time.Time) error {
+	c.readDeadline.set(t)
+	return nil
+}
+
+func (c *pipeConn) SetWriteDeadline(t time.Time) error {
+	c.writeDeadline.set(t)
+	return nil
+}
+
+func (c *pipeConn) RemoteAddr() net.Addr { return pipeAddr{} }
+
+func (c *pipeConn) Close() error {
+	c.closeOnce.Do(func() { close(c.done) })
+	return nil
+}
+
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string { return "pipe" }
+func (pipeAddr) String() string  { return "pipe" }
+
+// pipeDeadline is a deadline that can be changed while a read or write is
+// waiting on it.
+type pipeDeadline struct {
+	mu     sync.Mutex
+	timer  *time.Timer
+	cancel chan struct{} // Closed when the deadline passes
+}
+
+func makePipeDeadline() pipeDeadline {
+	return pipeDeadline{cancel: make(chan struct{})}
+}
+
+func (d *pipeDeadline) set(t time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.timer != nil && !d.timer.Stop() {
+		// The timer fired, wait for it to close cancel
+		<-d.cancel
+	}
+	d.timer = nil
+
+	closed := isClosedChan(d.cancel)
+	if t.IsZero() {
+		if closed {
+			d.cancel = make(chan struct{})
+		}
+		return
+	}
+	if dur := time.Until(t); dur > 0 {
+		if closed {
+			d.cancel = make(chan struct{})
+		}
+		cancel := d.cancel
+		d.timer = time.AfterFunc(dur, func() {
+			close(cancel)
+		})
+		return
+	}
+	if !closed {
+		close(d.cancel)
+	}
+}
+
+func (d *pipeDeadline) wait() chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.cancel
+}
+
+func isClosedChan(c <-chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
+// PipeListener is a Listener for servers in the same process as their
+// clients, who connect with Dial.
+type PipeListener struct {
+	conns     chan Conn
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewPipeListener() *PipeListener {
+	return &PipeListener{
+		conns: make(chan Conn),
+		done:  make(chan struct{}),
+	}
+}
+
+// Dial connects to the listener, blocking until the connection is
+// accepted.
+func (l *PipeListener) Dial() (Conn, error) {
+	client, server := Pipe()
+	select {
+	case l.conns <- server:
+		return client, nil
+	case <-l.done:
+		return nil, ErrClosed
+	}
+}
+
+func (l *PipeListener) Accept() (Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, ErrClosed
+	}
+}
+
+func (l *PipeListener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *PipeListener) Addr() net.Addr { return pipeAddr{} }
@@ -0,0 +1,192 @@
+package transport
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestPipeMessagesArriveInOrder(t *testing.T) {
+	a, b := Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	for i := 0; i < 10; i++ {
+		if err := a.WriteMessage([]byte{byte(i)}); err != nil {
+			t.Fatalf("write %d: %v", i, err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		data, err := b.ReadMessage()
+		if err != nil {
+			t.Fatalf("read %d: %v", i, err)
+		}
+		if !bytes.Equal(data, []byte{byte(i)}) {
+			t.Fatalf("read %d: got %v", i, data)
+		}
+	}
+}
+
+func TestPipeWriteCopiesMessage(t *testing.T) {
+	a, b := Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	data := []byte("hello")
+	a.WriteMessage(data)
+	data[0] = 'j'
+	got, err := b.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello" {
+		t.Fatalf("got %q, want %q", got, "hello")
+	}
+}
+
+func TestPipeCloseHandshake(t *testing.T) {
+	a, b := Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	a.WriteMessage([]byte("last"))
+	if err := a.WriteClose(ClosePolicyViolation, "bye"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.WriteMessage([]byte("too late")); err != ErrClosed {
+		t.Fatalf("write after close: got %v, want %v", err, ErrClosed)
+	}
+
+	// The peer reads what came before the close message, then the close
+	if data, err := b.ReadMessage(); err != nil || string(data) != "last" {
+		t.Fatalf("got %q, %v", data, err)
+	}
+	_, err := b.ReadMessage()
+	closeErr, ok := err.(*CloseError)
+	if !ok || closeErr.Code != ClosePolicyViolation || closeErr.Text != "bye" {
+		t.Fatalf("got %v, want close %d", err, ClosePolicyViolation)
+	}
+
+	// And replies, which ends the handshake
+	_, err = a.ReadMessage()
+	if closeErr, ok := err.(*CloseError); !ok || closeErr.Code != ClosePolicyViolation {
+		t.Fatalf("got %v, want close reply %d", err, ClosePolicyViolation)
+	}
+}
+
+func TestPipeAbruptClose(t *testing.T) {
+	a, b := Pipe()
+	defer b.Close()
+
+	a.WriteMessage([]byte("last"))
+	a.Close()
+	if data, err := b.ReadMessage(); err != nil || string(data) != "last" {
+		t.Fatalf("got %q, %v", data, err)
+	}
+	_, err := b.ReadMessage()
+	if closeErr, ok := err.(*CloseError); !ok || closeErr.Code != CloseAbnormalClosure {
+		t.Fatalf("got %v, want close %d", err, CloseAbnormalClosure)
+	}
+	if err := b.WriteMessage([]byte("anyone?")); err != ErrClosed {
+		t.Fatalf("write to closed peer: got %v, want %v", err, ErrClosed)
+	}
+	if _, err := a.ReadMessage(); err != ErrClosed {
+		t.Fatalf("read after close: got %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestPipeReadDeadline(t *testing.T) {
+	a, b := Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	b.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	if _, err := b.ReadMessage(); err != ErrTimeout {
+		t.Fatalf("got %v, want %v", err, ErrTimeout)
+	}
+}
+
+func TestPipeDeadlineChangesWhileWaiting(t *testing.T) {
+	a, b := Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	done := make(chan error)
+	go func() {
+		_, err := b.ReadMessage()
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	b.SetReadDeadline(time.Now())
+	select {
+	case err := <-done:
+		if err != ErrTimeout {
+			t.Fatalf("got %v, want %v", err, ErrTimeout)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("read didn't see the new deadline")
+	}
+}
+
+func TestPipeReadLimit(t *testing.T) {
+	a, b := Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	b.SetReadLimit(4)
+	a.WriteMessage([]byte("12345"))
+	if _, err := b.ReadMessage(); err != ErrReadLimit {
+		t.Fatalf("got %v, want %v", err, ErrReadLimit)
+	}
+	_, err := a.ReadMessage()
+	if closeErr, ok := err.(*CloseError); !ok || closeErr.Code != CloseMessageTooBig {
+		t.Fatalf("got %v, want close %d", err, CloseMessageTooBig)
+	}
+}
+
+func TestPipePing(t *testing.T) {
+	a, b := Pipe()
+	defer b.Close()
+
+	pongs := 0
+	a.SetPongHandler(func() { pongs++ })
+	if err := a.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if pongs != 1 {
+		t.Fatalf("got %d pongs, want 1", pongs)
+	}
+	b.Close()
+	if err := a.Ping(); err != ErrClosed {
+		t.Fatalf("ping closed peer: got %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestPipeListener(t *testing.T) {
+	l := NewPipeListener()
+	accepted := make(chan Conn)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			t.Error(err)
+		}
+		accepted <- conn
+	}()
+	client, err := l.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := <-accepted
+	client.WriteMessage([]byte("hi"))
+	if data, err := server.ReadMessage(); err != nil || string(data) != "hi" {
+		t.Fatalf("got %q, %v", data, err)
+	}
+
+	l.Close()
+	if _, err := l.Accept(); err != ErrClosed {
+		t.Fatalf("accept: got %v, want %v", err, ErrClosed)
+	}
+	if _, err := l.Dial(); err != ErrClosed {
+		t.Fatalf("dial: got %v, want %v", err, ErrClosed)
+	}
+}
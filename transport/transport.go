@@ -0,0 +1,102 @@
+// Package transport is the connection gameserver and gameclient send
+// messages over. Websockets are used over the network, and pipes connect a
+// server and clients in the same process, such as in tests.
+package transport
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Close codes sent with WriteClose, the same as websocket close codes.
+const (
+	CloseNormalClosure   = 1000
+	CloseGoingAway       = 1001
+	CloseAbnormalClosure = 1006
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseTryAgainLater   = 1013
+)
+
+var (
+	ErrClosed    = errors.New("Connection is closed.")
+	ErrTimeout   = errors.New("Connection deadline exceeded.")
+	ErrReadLimit = errors.New("Message is larger than the read limit.")
+)
+
+// Conn is a connection that sends and receives whole messages.
+//
+// Like a websocket connection, it supports one concurrent reader and one
+// concurrent writer. Close can be called at any time.
+type Conn interface {
+	// ReadMessage blocks until the next message arrives. It returns a
+	// *CloseError once the peer has closed the connection.
+	ReadMessage() ([]byte, error)
+
+	// WriteMessage sends a message.
+	WriteMessage(data []byte) error
+
+	// WriteClose starts the close handshake with the given close code.
+	// The peer's ReadMessage returns a *CloseError with it, and ours does
+	// once they reply.
+	WriteClose(code int, text string) error
+
+	// Ping checks the peer is still there, the pong handler is called
+	// when they answer.
+	Ping() error
+	SetPongHandler(h func())
+
+	// SetReadLimit is the largest message ReadMessage accepts. Larger
+	// messages close the connection with CloseMessageTooBig.
+	SetReadLimit(limit int64)
+
+	// Deadlines for reading and writing, a zero time means none. Once
+	// passed, reads and writes fail with a timeout.
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+
+	RemoteAddr() net.Addr
+
+	// Close closes the connection without the close handshake.
+	Close() error
+}
+
+// Listener accepts connections for a server.
+type Listener interface {
+	// Accept blocks until the next connection arrives. It returns
+	// ErrClosed once the listener is closed.
+	Accept() (Conn, error)
+	Close() error
+	Addr() net.Addr
+}
+
+// CloseError is returned by ReadMessage once the connection is closed.
+type CloseError struct {
+	Code int
+	Text string
+}
+
+func (err *CloseError) Error() string {
+	s := "Connection closed with code " + strconv.Itoa(err.Code)
+	if err.Text != "" {
+		s += ": " + err.Text
+	}
+	return s + "."
+}
+
+// IsUnexpectedCloseError reports whether err is a *CloseError with a code
+// that isn't one of expectedCodes.
+func IsUnexpectedCloseError(err error, expectedCodes ...int) bool {
+	closeErr, ok := err.(*CloseError)
+	if !ok {
+		return false
+	}
+	for _, code := range expectedCodes {
+		if closeErr.Code == code {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package transport
+
+import (
+	"net"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// websocketConn is a Conn over a Gorilla websocket connection.
+type websocketConn struct {
+	conn *websocket.Conn
+}
+
+// NewWebsocketConn wraps a websocket connection, such as one from
+// websocket.Upgrader. Messages are sent as binary messages.
+func NewWebsocketConn(conn *websocket.Conn) Conn {
+	return &websocketConn{conn: conn}
+}
+
+func (c *websocketConn) ReadMessage() ([]byte, error) {
+	_, data, err := c.conn.ReadMessage()
+	if err, ok := err.(*websocket.CloseError); ok {
+		return nil, &CloseError{
+			Code: err.Code,
+			Text: err.Text,
+		}
+	}
+	if err == websocket.ErrReadLimit {
+		return nil, ErrReadLimit
+	}
+	return data, err
+}
+
+func (c *websocketConn) WriteMessage(data []byte) error {
+	w, err := c.conn.NextWriter(websocket.BinaryMessage)
+	if err != nil {
+		return err
+	}
+	w.Write(data)
+	return w.Close()
+}
+
+func (c *websocketConn) WriteClose(code int, text string) error {
+	return c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, text))
+}
+
+func (c *websocketConn) Ping() error {
+	return c.conn.WriteMessage(websocket.PingMessage, nil)
+}
+
+func (c *websocketConn) SetPongHandler(h func()) {
+	c.conn.SetPongHandler(func(string) error {
+		h()
+		return nil
+	})
+}
+
+func (c *websocketConn) SetReadLimit(limit int64)           { c.conn.SetReadLimit(limit) }
+func (c *websocketConn) SetReadDeadline(t time.Time) error  { return c.conn.SetReadDeadline(t) }
+func (c *websocketConn) SetWriteDeadline(t time.Time) error { return c.conn.SetWriteDeadline(t) }
+func (c *websocketConn) RemoteAddr() net.Addr               { return c.conn.RemoteAddr() }
+func (c *websocketConn) Close() error                       { return c.conn.Close() }
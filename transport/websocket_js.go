@@ -0,0 +1,71 @@
+// +build js
+
+package transport
+
+import (
+	"net"
+	"time"
+
+	"github.com/gopherjs/websocket"
+)
+
+// defaultReadLimit is the size of the buffer messages are read into until
+// SetReadLimit is called.
+const defaultReadLimit = 1024
+
+// jsConn is a Conn over the browser's websocket.
+type jsConn struct {
+	conn net.Conn
+
+	// Size of the buffer messages are read into, a larger message would
+	// be split across reads.
+	readLimit int64
+}
+
+// DialWebsocket connects to a websocket server, ie. "ws://localhost:8080/ws".
+func DialWebsocket(url string) (Conn, error) {
+	conn, err := websocket.Dial(url) // Blocks until connection is established.
+	if err != nil {
+		return nil, err
+	}
+	return &jsConn{conn: conn, readLimit: defaultReadLimit}, nil
+}
+
+func (c *jsConn) ReadMessage() ([]byte, error) {
+	// todo(Jake): 2018-05-27
+	//
+	// Perhaps profile / figure out how keep allocations here low?
+	// Maybe this isnt even a big deal?
+	//
+	buf := make([]byte, c.readLimit)
+	size, err := c.conn.Read(buf) // Blocks until a WebSocket frame is received.
+	if err != nil {
+		return nil, err
+	}
+	return buf[:size], nil
+}
+
+func (c *jsConn) WriteMessage(data []byte) error {
+	// NOTE(Jake): 2018-05-27
+	//
+	// This is not blocking, at least for JavaScript impl. of Websocket.
+	//
+	// We also don't need to add a write deadline because its non-blocking.
+	_, err := c.conn.Write(data)
+	return err
+}
+
+// WriteClose closes the connection, the browser does the close handshake
+// itself.
+func (c *jsConn) WriteClose(code int, text string) error { return c.conn.Close() }
+
+// Ping does nothing, the browser answers the server's pings itself and
+// doesn't let us send any.
+func (c *jsConn) Ping() error             { return nil }
+func (c *jsConn) SetPongHandler(h func()) {}
+
+func (c *jsConn) SetReadLimit(limit int64)           { c.readLimit = limit }
+func (c *jsConn) SetReadDeadline(t time.Time) error  { return c.conn.SetReadDeadline(t) }
+func (c *jsConn) SetWriteDeadline(t time.Time) error { return nil }
+func (c *jsConn) RemoteAddr() net.Addr               { return c.conn.RemoteAddr() }
+func (c *jsConn) Close() error                       { return c.conn.Close() }
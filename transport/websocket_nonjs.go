@@ -0,0 +1,16 @@
+// +build !js
+
+package transport
+
+import (
+	"github.com/gorilla/websocket"
+)
+
+// DialWebsocket connects to a websocket server, ie. "ws://localhost:8080/ws".
+func DialWebsocket(url string) (Conn, error) {
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return NewWebsocketConn(conn), nil
+}